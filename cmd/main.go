@@ -10,15 +10,22 @@ import (
 
 var rootCmd cobra.Command
 
-func execServeSite(sitePath string, watchMode bool, port int) {
-	server.Serve(sitePath, watchMode, port)
+func execServeSite(sitePath string, watchMode bool, host string, port int, notFoundPage string) error {
+	return server.Serve(server.Config{
+		SitePath:     sitePath,
+		Host:         host,
+		Port:         port,
+		NotFoundPage: notFoundPage,
+	}, watchMode)
 }
 
 func init() {
 
 	var sitePath string
 	var watchMode bool
+	var host string
 	var port int
+	var notFoundPage string
 
 	rootCmd = cobra.Command{
 		Use:   "gengo",
@@ -29,20 +36,24 @@ func init() {
 	}
 
 	var serveCmd = &cobra.Command{
-		Use:   "serve",
-		Short: "Serves the static site",
-		Long:  `Serves the static site.`,
-		Run: func(cmd *cobra.Command, args []string) {
-			execServeSite(sitePath, watchMode, port)
+		Use:          "serve",
+		Short:        "Serves the static site",
+		Long:         `Serves the static site.`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return execServeSite(sitePath, watchMode, host, port, notFoundPage)
 		},
 	}
 
 	serveCmd.Flags().StringVar(&sitePath, "site", "site", "Site directory")
 	serveCmd.Flags().BoolVar(&watchMode, "watch", false, "Enable watch mode with hot reload")
+	serveCmd.Flags().StringVar(&host, "host", "localhost", "Address to serve on, 0.0.0.0 to browse the site from other devices")
 	serveCmd.Flags().IntVar(&port, "port", 3000, "Port to serve on")
+	serveCmd.Flags().StringVar(&notFoundPage, "not-found", "404.html", "Page served for missing files, relative to the site directory")
 
 	rootCmd.AddCommand(cli.NewGenerateCommand())
 	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(cli.NewDevCommand())
 	rootCmd.AddCommand(cli.NewVersionCommand())
 }
 
@@ -6,6 +6,7 @@ require (
 	github.com/charmbracelet/lipgloss v1.1.0
 	github.com/fsnotify/fsnotify v1.9.0
 	github.com/gorilla/websocket v1.5.3
+	github.com/pelletier/go-toml/v2 v2.2.3
 	github.com/posthog/posthog-go v1.5.2
 	github.com/saasuke-labs/nagare v0.0.11
 	github.com/spf13/cobra v1.9.1
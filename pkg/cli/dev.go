@@ -0,0 +1,216 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/saasuke-labs/gengo/pkg/generator"
+	"github.com/saasuke-labs/gengo/pkg/server"
+	"github.com/saasuke-labs/gengo/pkg/telemetry"
+	"github.com/saasuke-labs/gengo/pkg/watcher"
+	"github.com/spf13/cobra"
+)
+
+func NewDevCommand() *cobra.Command {
+
+	var manifestPaths []string
+	var outputPath string
+	var host string
+	var port int
+	var drafts bool
+	var future bool
+	var expired bool
+
+	var devCmd = &cobra.Command{
+		Use:   "dev",
+		Short: "Build, watch and serve the site with live reload",
+		Long: `Generate the site, serve it and rebuild it whenever one of its inputs changes:
+manifests, templates, partials, pages, data files and static assets. Browsers
+reload once the rebuild finishes.`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			defer telemetry.Close()
+			telemetry.Track("dev-started", map[string]interface{}{
+				"command": "dev",
+			})
+
+			return Dev(manifestPaths, outputPath, host, port, generator.BuildOptions{
+				Drafts:  drafts,
+				Future:  future,
+				Expired: expired,
+			})
+		},
+	}
+
+	devCmd.Flags().StringArrayVar(&manifestPaths, "manifest", []string{"gengo.yaml"}, "Path to the manifest file")
+	devCmd.Flags().StringVar(&outputPath, "output", "output", "Output directory")
+	devCmd.Flags().StringVar(&host, "host", "localhost", "Address to serve on, 0.0.0.0 to browse the site from other devices")
+	devCmd.Flags().IntVar(&port, "port", 3000, "Port to serve on")
+	devCmd.Flags().BoolVar(&drafts, "drafts", false, "Include draft pages")
+	devCmd.Flags().BoolVar(&future, "future", false, "Include pages with a published-at date in the future")
+	devCmd.Flags().BoolVar(&expired, "expired", false, "Include pages with an expires-at date in the past")
+
+	return devCmd
+}
+
+// Dev builds the site, serves it and rebuilds it on every change until it
+// is interrupted or the server fails. Failed builds are reported in the
+// terminal and in the browsers, and the last good build keeps being served.
+func Dev(manifestPaths []string, outputPath string, host string, port int, opts generator.BuildOptions) error {
+	srv := server.New(server.Config{
+		SitePath:   outputPath,
+		Host:       host,
+		Port:       port,
+		LiveReload: true,
+	})
+
+	_, inputs, errs := build(manifestPaths, outputPath, opts, nil)
+	srv.ReportErrors(buildErrors(errs)...)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- srv.ListenAndServe(ctx)
+	}()
+
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- watchInputs(manifestPaths, outputPath, inputs, func(changed []string) []string {
+			// Files that failed are not affected by the change, but may
+			// build now, e.g. when the broken template was fixed
+			changed = retryFailed(changed, errs)
+
+			var urls, inputs []string
+			urls, inputs, errs = build(manifestPaths, outputPath, opts, changed)
+			srv.ReportErrors(buildErrors(errs)...)
+			if len(urls) > 0 {
+				srv.Reload(urls...)
+			}
+			return inputs
+		})
+	}()
+
+	select {
+	case err := <-serverErr:
+		return err
+	case err := <-watchErr:
+		return err
+	}
+}
+
+// build regenerates the files affected by the changed paths, or the whole
+// site when changed is nil. It returns the urls of the regenerated files,
+// the input paths of the site and the errors of the files that failed. Only
+// the summary is printed, the progress screen would hide the messages of the
+// server.
+func build(manifestPaths []string, outputPath string, opts generator.BuildOptions, changed []string) ([]string, []string, []error) {
+	files, ch, inputs, err := generator.RebuildSiteAsync(manifestPaths, outputPath, opts, changed)
+	if err != nil {
+		fmt.Println(err)
+		return nil, nil, []error{err}
+	}
+
+	report := generator.NewBuildReport(len(files))
+	urls := make([]string, 0)
+	for progress := range ch {
+		report.Add(progress)
+		if progress.Status == generator.Completed && progress.Url != "" {
+			urls = append(urls, progress.Url)
+		}
+	}
+
+	fmt.Print(report)
+
+	errs := make([]error, 0, len(report.Failures))
+	for _, failure := range report.Failures {
+		errs = append(errs, failure.Err)
+	}
+	return urls, inputs, errs
+}
+
+// retryFailed adds the input files of the failed builds to the changed
+// paths. Errors without a file, such as a broken manifest, rebuild the whole
+// site.
+func retryFailed(changed []string, errs []error) []string {
+	for _, err := range errs {
+		var buildErr *generator.BuildError
+		if !errors.As(err, &buildErr) || buildErr.File == "" {
+			return nil
+		}
+		changed = append(changed, buildErr.File)
+	}
+	return changed
+}
+
+// buildErrors describes the errors for the browsers, with the file and
+// template line that caused them when known
+func buildErrors(errs []error) []server.BuildError {
+	described := make([]server.BuildError, 0, len(errs))
+
+	for _, err := range errs {
+		var buildErr *generator.BuildError
+		if errors.As(err, &buildErr) {
+			described = append(described, server.BuildError{
+				File:     buildErr.File,
+				Template: buildErr.Template,
+				Line:     buildErr.Line,
+				Column:   buildErr.Column,
+				Message:  buildErr.Err.Error(),
+			})
+		} else {
+			described = append(described, server.BuildError{Message: err.Error()})
+		}
+	}
+
+	return described
+}
+
+// watchInputs calls rebuild with the changed paths whenever inputs of the
+// site change. inputs are the dependencies returned by the last build, and
+// rebuild returns the ones of the new build, so the watched paths follow
+// the manifests and pages without scheduling the site again. It blocks
+// until watching fails.
+func watchInputs(manifestPaths []string, outputPath string, inputs []string, rebuild func(changed []string) []string) error {
+	w, err := watcher.New(outputPath, generator.CacheDir(manifestPaths))
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	refresh := func(inputs []string) error {
+		paths, err := generator.InputPaths(manifestPaths, inputs)
+		if err != nil {
+			return err
+		}
+		return w.Watch(paths)
+	}
+
+	// A manifest broken from the start is watched alone, so fixing it
+	// triggers the first successful build. The error is the one the
+	// initial build already reported.
+	if err := refresh(inputs); err != nil {
+		fmt.Println(err)
+		if err := w.Watch(manifestPaths); err != nil {
+			return err
+		}
+	}
+
+	w.Run(func(changed []string) {
+		fmt.Printf("Changed: %s\n", strings.Join(changed, ", "))
+		inputs := rebuild(changed)
+
+		// Keep watching the previous paths when the manifest is broken
+		if err := refresh(inputs); err != nil {
+			fmt.Println(err)
+		}
+	})
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/saasuke-labs/gengo/pkg/generator"
+	"github.com/saasuke-labs/gengo/pkg/server"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryFailed(t *testing.T) {
+	errs := []error{
+		&generator.BuildError{File: "blog/broken.md", Err: errors.New("boom")},
+	}
+	assert.Equal(t, []string{"page.html", "blog/broken.md"}, retryFailed([]string{"page.html"}, errs))
+	assert.Equal(t, []string{"page.html"}, retryFailed([]string{"page.html"}, nil))
+
+	// Errors without a file rebuild the whole site
+	errs = append(errs, errors.New("unknown"))
+	assert.Nil(t, retryFailed([]string{"page.html"}, errs))
+}
+
+func TestBuildErrors(t *testing.T) {
+	errs := []error{
+		&generator.BuildError{
+			File:     "blog/post.md",
+			Template: "templates/page.html",
+			Line:     12,
+			Err:      errors.New("undefined function"),
+		},
+		errors.New("manifest not found"),
+	}
+
+	assert.Equal(t, []server.BuildError{
+		{File: "blog/post.md", Template: "templates/page.html", Line: 12, Message: "undefined function"},
+		{Message: "manifest not found"},
+	}, buildErrors(errs))
+	assert.Empty(t, buildErrors(nil))
+}
+
+func TestWatchInputs_BrokenManifest(t *testing.T) {
+	dir := t.TempDir()
+	manifestPath := filepath.Join(dir, "gengo.yaml")
+	assert.NoError(t, os.WriteFile(manifestPath, []byte("sections: ["), 0644))
+
+	rebuilt := make(chan []string, 1)
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- watchInputs([]string{manifestPath}, filepath.Join(dir, "output"), nil, func(changed []string) []string {
+			select {
+			case rebuilt <- changed:
+			default:
+			}
+			return nil
+		})
+	}()
+
+	// The manifest is written until the watcher notices it, as it may not
+	// be watched yet
+	timeout := time.After(5 * time.Second)
+	for {
+		assert.NoError(t, os.WriteFile(manifestPath, []byte("title: Fixed\n"), 0644))
+
+		select {
+		case changed := <-rebuilt:
+			assert.Contains(t, changed, manifestPath)
+			return
+		case err := <-watchErr:
+			t.Fatalf("watching stopped: %v", err)
+		case <-timeout:
+			t.Fatal("the fixed manifest did not trigger a rebuild")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
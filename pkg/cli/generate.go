@@ -2,12 +2,10 @@ package cli
 
 import (
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/saasuke-labs/gengo/pkg/generator"
 	"github.com/saasuke-labs/gengo/pkg/telemetry"
-	"github.com/saasuke-labs/gengo/pkg/watcher"
 	"github.com/spf13/cobra"
 )
 
@@ -17,26 +15,37 @@ func NewGenerateCommand() *cobra.Command {
 	var outputPath string
 	var watchMode bool
 	var plainMode bool
+	var noCache bool
+	var drafts bool
+	var future bool
+	var expired bool
 
 	var generateCmd = &cobra.Command{
-		Use:   "generate",
-		Short: "Generate the static site",
-		Long:  `Generate the static site from the manifest.yaml file and output it to the specified directory.`,
-		Run: func(cmd *cobra.Command, args []string) {
+		Use:          "generate",
+		Short:        "Generate the static site",
+		Long:         `Generate the static site from the manifest.yaml file and output it to the specified directory.`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
 			defer telemetry.Close()
 			telemetry.Track("generate-started", map[string]interface{}{
 				"command": "generate",
 				"plain":   plainMode,
 			})
+			opts := generator.BuildOptions{
+				NoCache: noCache,
+				Drafts:  drafts,
+				Future:  future,
+				Expired: expired,
+			}
 			if plainMode {
-				SilentGenerate(manifestPaths, outputPath)
-				return
+				return SilentGenerate(manifestPaths, outputPath, opts)
 			}
-			Generate(manifestPaths, outputPath, watchMode)
+			err := Generate(manifestPaths, outputPath, watchMode, opts)
 			telemetry.Track("generate-completed", map[string]interface{}{
 				"command": "generate",
 				"plain":   plainMode,
 			})
+			return err
 		},
 	}
 
@@ -44,12 +53,22 @@ func NewGenerateCommand() *cobra.Command {
 	generateCmd.Flags().StringVar(&outputPath, "output", "output", "Output directory")
 	generateCmd.Flags().BoolVar(&watchMode, "watch", false, "Enable watch mode with hot reload")
 	generateCmd.Flags().BoolVar(&plainMode, "plain", false, "Plain output. Useful for non-interactive shell")
+	generateCmd.Flags().BoolVar(&noCache, "no-cache", false, "Ignore the build cache and regenerate every file")
+	generateCmd.Flags().BoolVar(&drafts, "drafts", false, "Include draft pages")
+	generateCmd.Flags().BoolVar(&future, "future", false, "Include pages with a published-at date in the future")
+	generateCmd.Flags().BoolVar(&expired, "expired", false, "Include pages with an expires-at date in the past")
 
 	return generateCmd
 }
 
-func generate(manifestPaths []string, outputPath string) {
-	files, ch := generator.GenerateSiteAsync(manifestPaths, outputPath)
+// generate regenerates the files affected by the changed paths, or the
+// whole site when changed is nil. It returns the input paths of the site,
+// also when some files failed.
+func generate(manifestPaths []string, outputPath string, opts generator.BuildOptions, changed []string) ([]string, error) {
+	files, ch, inputs, err := generator.RebuildSiteAsync(manifestPaths, outputPath, opts, changed)
+	if err != nil {
+		return nil, err
+	}
 
 	filesStatuses := make(map[string]generator.FileStatus)
 	fileNames := make([]string, len(files))
@@ -59,24 +78,22 @@ func generate(manifestPaths []string, outputPath string) {
 		filesStatuses[file.Filename] = file.Status
 	}
 
-	completed := 0
+	report := generator.NewBuildReport(len(files))
 
 	//fmt.Println("Waiting for updates...")
 	for {
 		select {
 		case progress, ok := <-ch:
 			if !ok {
-				fmt.Printf("Generated %d / %d files\n", completed, len(files))
-				return
+				fmt.Print(report)
+				return inputs, report.Err()
 			}
 
-			if progress.Status == generator.Completed || progress.Status == generator.Failed {
-				completed++
-			}
+			report.Add(progress)
 
 			filesStatuses[progress.Filename] = progress.Status
 
-			UpdateScreen("Files progress:", fileNames, filesStatuses, completed, len(files))
+			UpdateScreen("Files progress:", fileNames, filesStatuses, report.Done(), len(files))
 
 		default:
 			// No updates available, wait a bit before refreshing
@@ -85,40 +102,44 @@ func generate(manifestPaths []string, outputPath string) {
 	}
 }
 
-func Generate(manifestPaths []string, outputPath string, watchMode bool) {
-	generate(manifestPaths, outputPath)
+func Generate(manifestPaths []string, outputPath string, watchMode bool, opts generator.BuildOptions) error {
+	inputs, err := generate(manifestPaths, outputPath, opts, nil)
 
 	if watchMode {
-		// TODO - See how to find this directory from posts.yaml
-		go watcher.WatchDir("./blog", func(file string) {
-			// TODO - Optimize and generate only the changed file
-			//fmt.Println(("Generating site..."))
-			generate(manifestPaths, outputPath)
-
+		return watchInputs(manifestPaths, outputPath, inputs, func(changed []string) []string {
+			inputs, err := generate(manifestPaths, outputPath, opts, changed)
+			if err != nil {
+				fmt.Println(err)
+			}
+			return inputs
 		})
-
-		//fmt.Println("Press any key to exit...")
-		var b []byte = make([]byte, 1)
-		os.Stdin.Read(b)
 	}
+
+	return err
 }
 
-func SilentGenerate(manifestPaths []string, outputPath string) {
-	files, ch := generator.GenerateSiteAsync(manifestPaths, outputPath)
+func SilentGenerate(manifestPaths []string, outputPath string, opts generator.BuildOptions) error {
+	files, ch, err := generator.GenerateSiteAsync(manifestPaths, outputPath, opts)
+	if err != nil {
+		return err
+	}
 
-	completed := 0
+	report := generator.NewBuildReport(len(files))
 
 	//fmt.Println("Waiting for updates...")
 	for {
 		select {
 		case progress, ok := <-ch:
 			if !ok {
-				fmt.Printf("Generated %d / %d files\n", completed, len(files))
-				return
+				fmt.Print(report)
+				return report.Err()
 			}
 
-			if progress.Status == generator.Completed || progress.Status == generator.Failed {
-				completed++
+			report.Add(progress)
+
+			if progress.Status == generator.Failed {
+				fmt.Printf("File %s: %s (%v)\n", progress.Filename, progress.Status, progress.Err)
+			} else if progress.Status != generator.Started {
 				fmt.Printf("File %s: %s\n", progress.Filename, progress.Status)
 			}
 
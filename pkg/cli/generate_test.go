@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/saasuke-labs/gengo/pkg/generator"
 	"github.com/saasuke-labs/gengo/pkg/testutils"
 	"github.com/stretchr/testify/assert"
 )
@@ -44,7 +45,8 @@ func TestGeneration(t *testing.T) {
 	absInput, absOutput, absExpectedOutput := prepareDirectories("simple-blog")
 	os.RemoveAll(absOutput)
 
-	SilentGenerate([]string{path.Join(absInput, "gengo.yaml")}, absOutput)
+	err := SilentGenerate([]string{path.Join(absInput, "gengo.yaml")}, absOutput, generator.BuildOptions{})
+	assert.NoError(t, err)
 
 	if _, err := os.Stat(absOutput); os.IsNotExist(err) {
 		t.Fatalf("Output directory was not created: %v", err)
@@ -26,6 +26,7 @@ var (
 		generator.Started:   "◔",
 		generator.Completed: "●",
 		generator.Failed:    "✗",
+		generator.Skipped:   "◌",
 	}
 
 	statusStyles = map[generator.FileStatus]lipgloss.Style{
@@ -33,6 +34,7 @@ var (
 		generator.Started:   lipgloss.NewStyle().Foreground(warningColor),
 		generator.Completed: lipgloss.NewStyle().Foreground(successColor),
 		generator.Failed:    lipgloss.NewStyle().Foreground(dangerColor),
+		generator.Skipped:   lipgloss.NewStyle().Foreground(infoColor),
 	}
 
 	// Container styles
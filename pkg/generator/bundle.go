@@ -0,0 +1,117 @@
+package generator
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Resource is a file of a page bundle
+type Resource struct {
+	Name string // Path relative to the bundle directory, e.g. images/cover.png
+	Url  string
+}
+
+// isBundle reports whether the page is a page bundle: an index page in its
+// own directory, next to the images and files it uses. An index page at the
+// root of the manifest or content directory is a regular page, since its
+// directory holds the rest of the site.
+func isBundle(page Page) bool {
+	name := filepath.Base(page.MarkdownPath)
+	if strings.TrimSuffix(name, filepath.Ext(name)) != "index" {
+		return false
+	}
+
+	rel, err := filepath.Rel(filepath.Clean(page.contentDir), filepath.Dir(page.MarkdownPath))
+	if err != nil {
+		return false
+	}
+	return rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// isBundleDir reports whether dir holds a page bundle
+func isBundleDir(dir string) bool {
+	for _, name := range []string{"index.md", "index.html"} {
+		if isFile(filepath.Join(dir, name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// bundleResources returns the files of a page bundle, sorted by name.
+// Pages, hidden files like the .gengo-cache directory, nested bundles and
+// the output directory are not resources of the bundle. The url of every
+// resource is relative to page.Url.
+func bundleResources(baseDir, outDir string, page Page) ([]Resource, error) {
+	if !isBundle(page) {
+		return nil, nil
+	}
+
+	bundleDir := filepath.Dir(getFullPath(baseDir, page.MarkdownPath))
+	names := make([]string, 0)
+
+	err := filepath.WalkDir(bundleDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == bundleDir {
+			return nil
+		}
+		if d.IsDir() && outDir != "" && absolutePath(p) == absolutePath(outDir) {
+			return filepath.SkipDir
+		}
+		if strings.HasPrefix(d.Name(), ".") || (d.IsDir() && isBundleDir(p)) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		ext := filepath.Ext(p)
+		if ext == ".md" || ext == ".html" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(bundleDir, p)
+		if err != nil {
+			return err
+		}
+		names = append(names, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fileError(bundleDir, err)
+	}
+
+	sort.Strings(names)
+
+	resources := make([]Resource, 0, len(names))
+	for _, name := range names {
+		resources = append(resources, Resource{
+			Name: name,
+			Url:  path.Join(path.Dir(page.Url), name),
+		})
+	}
+	return resources, nil
+}
+
+// resourceTasks copies the resources of a page bundle next to the page
+// generated at outPath
+func resourceTasks(baseDir, outPath string, page Page) []Task {
+	bundleDir := filepath.Dir(getFullPath(baseDir, page.MarkdownPath))
+
+	tasks := make([]Task, 0, len(page.Resources))
+	for _, resource := range page.Resources {
+		tasks = append(tasks, &CopyTask{
+			FromPath: filepath.Join(bundleDir, filepath.FromSlash(resource.Name)),
+			ToPath:   filepath.Join(filepath.Dir(outPath), filepath.FromSlash(resource.Name)),
+		})
+	}
+	return tasks
+}
@@ -0,0 +1,118 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBundleResources(t *testing.T) {
+	dir := t.TempDir()
+	writeContent(t, dir, map[string]string{
+		"blog/post/index.md":            "# Post\n",
+		"blog/post/cover.png":           "png",
+		"blog/post/images/diagram.svg":  "svg",
+		"blog/post/notes.md":            "# Notes\n",
+		"blog/post/.DS_Store":           "",
+		"blog/post/nested/index.md":     "# Nested\n",
+		"blog/post/nested/photo.jpg":    "jpg",
+		"blog/post/public/index.html":   "<p>Post</p>",
+		"blog/post/.gengo-cache/a.json": "{}",
+		"blog/single.md":                "# Single\n",
+	})
+
+	resources, err := bundleResources(dir, filepath.Join(dir, "blog/post/public"), Page{MarkdownPath: "blog/post/index.md", Url: "/blog/post/index.html"})
+	assert.NoError(t, err)
+	assert.Equal(t, []Resource{
+		{Name: "cover.png", Url: "/blog/post/cover.png"},
+		{Name: "images/diagram.svg", Url: "/blog/post/images/diagram.svg"},
+	}, resources)
+
+	resources, err = bundleResources(dir, "", Page{MarkdownPath: "blog/single.md"})
+	assert.NoError(t, err)
+	assert.Empty(t, resources)
+}
+
+func TestSchedulePageBundles(t *testing.T) {
+	dir := t.TempDir()
+	writeContent(t, dir, map[string]string{
+		"posts/hello/index.md":         "# Hello\n\n![Cover](cover.png)\n\n<!--more-->\n",
+		"posts/hello/cover.png":        "png",
+		"posts/hello/images/chart.svg": "svg",
+		"layout.html":                  "{{ .HTML }}",
+		"page.html":                    "{{ .HTML }}{{ range .Page.Resources }}[{{ .Url }}]{{ end }}",
+		"section.html":                 "{{ range .Pages }}{{ .Summary }}{{ end }}",
+	})
+
+	manifest := ManifestFile{
+		DefaultLayoutTemplate:  "layout.html",
+		DefaultPageTemplate:    "page.html",
+		DefaultSectionTemplate: "section.html",
+		Sections: map[string]Section{
+			"blog": {ContentDir: "posts"},
+		},
+	}
+
+	outDir := t.TempDir()
+	tasks, _, err := scheduleTasks(manifest, dir, outDir, BuildOptions{})
+	assert.NoError(t, err)
+	for _, task := range tasks {
+		assert.NoError(t, task.Execute())
+	}
+
+	page, err := os.ReadFile(filepath.Join(outDir, "blog/hello/index.html"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(page), `src="/blog/hello/cover.png"`)
+	assert.Contains(t, string(page), "[/blog/hello/cover.png][/blog/hello/images/chart.svg]")
+
+	listing, err := os.ReadFile(filepath.Join(outDir, "blog/index.html"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(listing), `src="/blog/hello/cover.png"`)
+
+	for _, resource := range []string{"cover.png", "images/chart.svg"} {
+		assert.FileExists(t, filepath.Join(outDir, "blog/hello", resource))
+	}
+}
+
+func TestRootIndexIsNotABundle(t *testing.T) {
+	assert.False(t, isBundle(Page{MarkdownPath: "index.md"}))
+	assert.False(t, isBundle(Page{MarkdownPath: "docs/index.md", contentDir: "docs"}))
+	assert.True(t, isBundle(Page{MarkdownPath: "docs/guide/index.md", contentDir: "docs"}))
+	assert.Equal(t, "index", pageSlug(Page{MarkdownPath: "index.md"}))
+
+	dir := t.TempDir()
+	writeContent(t, dir, map[string]string{
+		"index.md":        "# Home\n",
+		"docs/index.md":   "# Docs\n",
+		"docs/intro.md":   "# Intro\n",
+		"public/old.html": "<p>Old</p>",
+		"layout.html":     "{{ .HTML }}",
+		"page.html":       "{{ .HTML }}",
+	})
+
+	manifest := ManifestFile{
+		DefaultLayoutTemplate: "layout.html",
+		DefaultPageTemplate:   "page.html",
+		Sections: map[string]Section{
+			"main": {Pages: []Page{{MarkdownPath: "index.md"}}},
+			"docs": {ContentDir: "docs"},
+		},
+	}
+
+	tasks, site, err := scheduleTasks(manifest, dir, filepath.Join(dir, "public"), BuildOptions{})
+	assert.NoError(t, err)
+
+	for _, task := range tasks {
+		_, copied := task.(*CopyTask)
+		assert.False(t, copied, "unexpected resource task %s", task.Name())
+	}
+
+	urls := make([]string, 0)
+	for _, page := range site.pages() {
+		assert.Empty(t, page.Resources)
+		urls = append(urls, page.Url)
+	}
+	assert.ElementsMatch(t, []string{"/main/index.html", "/docs/index.html", "/docs/intro.html"}, urls)
+}
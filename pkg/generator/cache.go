@@ -0,0 +1,219 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const buildCacheVersion = 1
+
+// Directory next to the manifest with the caches of the site
+const cacheDirName = ".gengo-cache"
+
+// CacheDir returns the directory the caches of the site are kept in. It is
+// next to the first manifest, like the inputs, but is not one of them.
+func CacheDir(manifestPaths []string) string {
+	return filepath.Join(filepath.Dir(manifestPaths[0]), cacheDirName)
+}
+
+// CacheableTask is implemented by tasks whose output only depends on
+// inputs that can be hashed before running them. When the fingerprint
+// matches the one recorded by the previous build the task is skipped.
+type CacheableTask interface {
+	Task
+	Fingerprint() (string, error)
+}
+
+// BuildCache keeps the fingerprint of every task output produced by the
+// last build. It is stored as JSON in the cache directory next to the
+// manifest, one file per output directory, as everything in the output
+// directory is served. Outputs missing on disk are always regenerated.
+type BuildCache struct {
+	Version int               `json:"version"`
+	Site    string            `json:"site"` // Hash of the site the outputs render
+	Tasks   map[string]string `json:"tasks"`
+
+	path string
+	mu   sync.Mutex
+}
+
+func newBuildCache(baseDir, outputDir string) *BuildCache {
+	return &BuildCache{
+		Version: buildCacheVersion,
+		Tasks:   make(map[string]string),
+		path:    buildCachePath(baseDir, outputDir),
+	}
+}
+
+// buildCachePath returns the file the cache of the builds of the site to
+// outputDir is stored in
+func buildCachePath(baseDir, outputDir string) string {
+	name := fmt.Sprintf("build-%s.json", hashStrings(absolutePath(outputDir))[:16])
+	return filepath.Join(baseDir, cacheDirName, name)
+}
+
+// loadBuildCache reads the cache of the builds to outputDir. A missing,
+// corrupt or outdated cache file results in an empty cache, forcing a full
+// build.
+func loadBuildCache(baseDir, outputDir string) *BuildCache {
+	cache := newBuildCache(baseDir, outputDir)
+
+	data, err := os.ReadFile(cache.path)
+	if err != nil {
+		return cache
+	}
+
+	var stored BuildCache
+	if err := json.Unmarshal(data, &stored); err != nil || stored.Version != buildCacheVersion {
+		return cache
+	}
+	if stored.Tasks != nil {
+		cache.Tasks = stored.Tasks
+	}
+	cache.Site = stored.Site
+
+	return cache
+}
+
+// IsFresh reports whether the output of the task was produced from inputs
+// with the same fingerprint and is still present on disk.
+func (c *BuildCache) IsFresh(output, fingerprint string) bool {
+	c.mu.Lock()
+	recorded, ok := c.Tasks[output]
+	c.mu.Unlock()
+
+	if !ok || recorded != fingerprint {
+		return false
+	}
+
+	_, err := os.Stat(output)
+	return err == nil
+}
+
+func (c *BuildCache) Record(output, fingerprint string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Tasks[output] = fingerprint
+}
+
+func (c *BuildCache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data, 0644)
+}
+
+// hashStrings returns a stable hash of the given values.
+func hashStrings(values ...string) string {
+	h := sha256.New()
+	for _, v := range values {
+		// Length prefix so that ("ab", "c") and ("a", "bc") differ
+		fmt.Fprintf(h, "%d:%s;", len(v), v)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// hashTask hashes the task configuration. It is encoded as JSON, which
+// follows pointers and sorts map keys, so the result is stable between runs.
+func hashTask(task Task) string {
+	data, err := json.Marshal(task)
+	if err != nil {
+		// Never report a stale task as fresh
+		return hashStrings(fmt.Sprintf("%T", task), err.Error(), fmt.Sprint(time.Now().UnixNano()))
+	}
+	return hashStrings(fmt.Sprintf("%T", task), string(data))
+}
+
+// hashPages hashes the content of the pages. It is left out of the JSON of
+// the tasks, so that a page is not rebuilt when another page changes, and
+// is only added to the tasks listing the pages.
+func hashPages(pages []Page) string {
+	hashes := make([]string, len(pages))
+	for i, page := range pages {
+		hashes[i] = page.ContentHash
+	}
+	return hashStrings(hashes...)
+}
+
+// hashFiles hashes the content of every path. Directories are walked and
+// every file inside them is hashed along with its relative path. Empty
+// paths are ignored, which is convenient for optional templates.
+func hashFiles(paths ...string) (string, error) {
+	h := sha256.New()
+
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+
+		fmt.Fprintf(h, "path:%s;", path)
+
+		if !isDirectory(path) {
+			if err := hashFile(h, path); err != nil {
+				return "", err
+			}
+			continue
+		}
+
+		files := make([]string, 0)
+		err := filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+		sort.Strings(files)
+
+		for _, file := range files {
+			rel, _ := filepath.Rel(path, file)
+			fmt.Fprintf(h, "file:%s;", rel)
+			if err := hashFile(h, file); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// hashManifests hashes the raw content of every manifest file, so any
+// manifest edit invalidates the whole cache.
+func hashManifests(manifestPaths []string) string {
+	hash, err := hashFiles(manifestPaths...)
+	if err != nil {
+		return ""
+	}
+	return hash
+}
@@ -0,0 +1,160 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func collectStatuses(t *testing.T, manifestPath, outputDir string, opts BuildOptions) map[string]FileStatus {
+	_, ch, err := GenerateSiteAsync([]string{manifestPath}, outputDir, opts)
+	if err != nil {
+		t.Fatalf("Error generating site: %v", err)
+	}
+
+	statuses := make(map[string]FileStatus)
+	for progress := range ch {
+		statuses[progress.Filename] = progress.Status
+	}
+	return statuses
+}
+
+func prepareSimpleBlog(t *testing.T) (string, string) {
+	inputDir := filepath.Join(t.TempDir(), "input")
+	err := copyDirectory("../../test-resources/simple-blog/input", inputDir)
+	assert.NoError(t, err)
+
+	return filepath.Join(inputDir, "gengo.yaml"), filepath.Join(t.TempDir(), "output")
+}
+
+func TestBuildCache_SkipsUnchangedTasks(t *testing.T) {
+	manifestPath, outputDir := prepareSimpleBlog(t)
+
+	first := collectStatuses(t, manifestPath, outputDir, BuildOptions{})
+	for name, status := range first {
+		assert.Equal(t, Completed, status, name)
+	}
+
+	second := collectStatuses(t, manifestPath, outputDir, BuildOptions{})
+	for name, status := range second {
+		assert.Equal(t, Skipped, status, name)
+	}
+}
+
+func TestBuildCache_RebuildsChangedPage(t *testing.T) {
+	manifestPath, outputDir := prepareSimpleBlog(t)
+	collectStatuses(t, manifestPath, outputDir, BuildOptions{})
+
+	markdownPath := filepath.Join(filepath.Dir(manifestPath), "blog1.md")
+	err := os.WriteFile(markdownPath, []byte("# Changed\n"), 0644)
+	assert.NoError(t, err)
+
+	statuses := collectStatuses(t, manifestPath, outputDir, BuildOptions{})
+	assert.Equal(t, Completed, statuses[filepath.Join(outputDir, "blog", "blog1.html")])
+	// Listings show the summary of the pages, so they are rebuilt too
+	assert.Equal(t, Completed, statuses[filepath.Join(outputDir, "blog", "index.html")])
+}
+
+func TestBuildCache_SkipsListingsWhenTemplateChanges(t *testing.T) {
+	manifestPath, outputDir := prepareSimpleBlog(t)
+	collectStatuses(t, manifestPath, outputDir, BuildOptions{})
+
+	templatePath := filepath.Join(filepath.Dir(manifestPath), "page.html")
+	err := os.WriteFile(templatePath, []byte("<article>{{ .HTML }}</article>"), 0644)
+	assert.NoError(t, err)
+
+	statuses := collectStatuses(t, manifestPath, outputDir, BuildOptions{})
+	assert.Equal(t, Completed, statuses[filepath.Join(outputDir, "blog", "blog1.html")])
+	assert.Equal(t, Skipped, statuses[filepath.Join(outputDir, "blog", "index.html")])
+}
+
+func TestBuildCache_StoredOutsideTheOutput(t *testing.T) {
+	manifestPath, outputDir := prepareSimpleBlog(t)
+	collectStatuses(t, manifestPath, outputDir, BuildOptions{})
+
+	entries, err := os.ReadDir(outputDir)
+	assert.NoError(t, err)
+	for _, entry := range entries {
+		assert.NotContains(t, entry.Name(), ".gengo-cache")
+	}
+
+	cache := loadBuildCache(filepath.Dir(manifestPath), outputDir)
+	assert.Contains(t, cache.Tasks, filepath.Join(outputDir, "blog", "blog1.html"))
+
+	// Every output directory has its own cache
+	otherDir := filepath.Join(t.TempDir(), "other")
+	statuses := collectStatuses(t, manifestPath, otherDir, BuildOptions{})
+	assert.Equal(t, Completed, statuses[filepath.Join(otherDir, "blog", "blog1.html")])
+	statuses = collectStatuses(t, manifestPath, outputDir, BuildOptions{})
+	assert.Equal(t, Skipped, statuses[filepath.Join(outputDir, "blog", "blog1.html")])
+}
+
+func TestBuildCache_RemovesStaleOutputs(t *testing.T) {
+	manifestPath, outputDir := prepareDependencySite(t)
+	collectStatuses(t, manifestPath, outputDir, BuildOptions{})
+
+	second := filepath.Join(filepath.Dir(manifestPath), "blog", "second.md")
+	assert.NoError(t, os.WriteFile(second, []byte("---\ndraft: true\n---\n# Second\n"), 0644))
+	assert.NoError(t, os.Remove(filepath.Join(filepath.Dir(manifestPath), "notes", "note.md")))
+
+	collectStatuses(t, manifestPath, outputDir, BuildOptions{})
+	assert.NoFileExists(t, filepath.Join(outputDir, "blog", "second.html"))
+	assert.NoFileExists(t, filepath.Join(outputDir, "notes", "note.html"))
+	assert.FileExists(t, filepath.Join(outputDir, "blog", "first.html"))
+	assert.FileExists(t, filepath.Join(outputDir, "notes", "index.html"))
+
+	// Drafts are generated again when included
+	collectStatuses(t, manifestPath, outputDir, BuildOptions{Drafts: true})
+	assert.FileExists(t, filepath.Join(outputDir, "blog", "second.html"))
+}
+
+func TestBuildCache_NoCacheRebuildsEverything(t *testing.T) {
+	manifestPath, outputDir := prepareSimpleBlog(t)
+	collectStatuses(t, manifestPath, outputDir, BuildOptions{})
+
+	statuses := collectStatuses(t, manifestPath, outputDir, BuildOptions{NoCache: true})
+	for name, status := range statuses {
+		assert.Equal(t, Completed, status, name)
+	}
+}
+
+func TestBuildCache_RebuildsMissingOutput(t *testing.T) {
+	manifestPath, outputDir := prepareSimpleBlog(t)
+	collectStatuses(t, manifestPath, outputDir, BuildOptions{})
+
+	pagePath := filepath.Join(outputDir, "blog", "blog1.html")
+	assert.NoError(t, os.Remove(pagePath))
+
+	statuses := collectStatuses(t, manifestPath, outputDir, BuildOptions{})
+	assert.Equal(t, Completed, statuses[pagePath])
+}
+
+func TestHashTask_LeavesSiteOut(t *testing.T) {
+	site := &Site{Pages: []Page{{Title: "First"}, {Title: "Second"}}}
+	task := &PageTask{OutputFile: "out/first.html", Site: site, Next: &site.Pages[1], Related: site.Pages}
+
+	before := hashTask(task)
+	site.Pages[1].Title = "Changed"
+	// The site is hashed once per build instead
+	assert.Equal(t, before, hashTask(task))
+	assert.NotEqual(t, hashSite(&Site{Pages: []Page{{Title: "First"}}}), hashSite(site))
+}
+
+func TestBuildCache_BodyEditOnlyRebuildsItsPage(t *testing.T) {
+	manifestPath, outputDir := prepareDependencySite(t)
+	collectStatuses(t, manifestPath, outputDir, BuildOptions{})
+
+	markdownPath := filepath.Join(filepath.Dir(manifestPath), "blog", "second.md")
+	assert.NoError(t, os.WriteFile(markdownPath, []byte("# Second\n\nA new paragraph.\n"), 0644))
+
+	statuses := collectStatuses(t, manifestPath, outputDir, BuildOptions{})
+	assert.Equal(t, Completed, statuses[filepath.Join(outputDir, "blog", "second.html")])
+	assert.Equal(t, Skipped, statuses[filepath.Join(outputDir, "blog", "first.html")])
+	assert.Equal(t, Skipped, statuses[filepath.Join(outputDir, "blog", "third.html")])
+	assert.Equal(t, Skipped, statuses[filepath.Join(outputDir, "notes", "note.html")])
+	// Listings show the summary of the pages
+	assert.Equal(t, Completed, statuses[filepath.Join(outputDir, "blog", "index.html")])
+	assert.Equal(t, Skipped, statuses[filepath.Join(outputDir, "notes", "index.html")])
+}
@@ -21,6 +21,14 @@ func isDirectory(path string) bool {
 	return fileInfo.IsDir()
 }
 
+func isFile(path string) bool {
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return fileInfo.Mode().IsRegular()
+}
+
 func copyFile(src, dst string) error {
 	// Open the source file
 	sourceFile, err := os.Open(src)
@@ -29,7 +37,11 @@ func copyFile(src, dst string) error {
 	}
 	defer sourceFile.Close()
 
-	// Create the destination file
+	// Create the destination file, bundle resources may be copied before
+	// the page creates its directory
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
 	destinationFile, err := os.Create(dst)
 	if err != nil {
 		return err
@@ -80,13 +92,22 @@ func (t CopyTask) Execute() error {
 	// If FromPAth is a directory, copy all files
 	// If FromPath is a file, copy the file
 	if isDirectory(t.FromPath) {
-		return copyDirectory(t.FromPath, t.ToPath)
+		return fileError(t.FromPath, copyDirectory(t.FromPath, t.ToPath))
 	} else {
-		return copyFile(t.FromPath, t.ToPath)
+		return fileError(t.FromPath, copyFile(t.FromPath, t.ToPath))
 	}
 
 }
 
+// Fingerprint implements CacheableTask
+func (t CopyTask) Fingerprint() (string, error) {
+	files, err := hashFiles(t.FromPath)
+	if err != nil {
+		return "", err
+	}
+	return hashStrings(hashTask(t), files), nil
+}
+
 func (t CopyTask) Name() string {
 	return t.ToPath
 }
@@ -0,0 +1,192 @@
+package generator
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Directory with the data files, relative to the manifest, when the
+// manifest does not configure one
+const defaultDataDir = "data"
+
+// dataDir returns the data directory configured in the manifest
+func dataDir(manifest ManifestFile, baseDir string) string {
+	if manifest.DataDir != "" {
+		return getFullPath(baseDir, manifest.DataDir)
+	}
+	return getFullPath(baseDir, defaultDataDir)
+}
+
+// Decoders of the supported data files, by extension
+var dataDecoders = map[string]func(content []byte) (interface{}, error){
+	".yaml": decodeYAMLData,
+	".yml":  decodeYAMLData,
+	".json": decodeJSONData,
+	".toml": decodeTOMLData,
+	".csv":  decodeCSVData,
+}
+
+// loadDataDir loads every data file of the directory, keyed by its path
+// without extension: data/team/members.yaml is available as
+// .Site.Data.team.members. A missing directory means the site has no data.
+func loadDataDir(dir string) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return data, nil
+	}
+
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return fileError(path, err)
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		decode, ok := dataDecoders[strings.ToLower(filepath.Ext(path))]
+		if !ok {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fileError(path, err)
+		}
+		value, err := decode(content)
+		if err != nil {
+			return dataError(path, err)
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return fileError(path, err)
+		}
+		keys := strings.Split(strings.TrimSuffix(filepath.ToSlash(rel), filepath.Ext(rel)), "/")
+
+		return fileError(path, setDataValue(data, keys, value))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// dataError wraps an error decoding a data file, with the line reported by
+// the decoder when its message does not already include it
+func dataError(file string, err error) error {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		return yamlError(file, err)
+	case ".toml":
+		var decodeErr *toml.DecodeError
+		if errors.As(err, &decodeErr) {
+			line, column := decodeErr.Position()
+			return &BuildError{File: file, Line: line, Column: column, Err: err}
+		}
+	}
+	return fileError(file, err)
+}
+
+// setDataValue sets the value at the nested keys, creating the maps of the
+// directories on the way.
+func setDataValue(data map[string]interface{}, keys []string, value interface{}) error {
+	for _, key := range keys[:len(keys)-1] {
+		child, ok := data[key]
+		if !ok {
+			child = make(map[string]interface{})
+			data[key] = child
+		}
+		childMap, ok := child.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("data key %q is both a file and a directory", key)
+		}
+		data = childMap
+	}
+
+	key := keys[len(keys)-1]
+	if _, exists := data[key]; exists {
+		return fmt.Errorf("data key %q is defined more than once", key)
+	}
+	data[key] = value
+	return nil
+}
+
+func decodeYAMLData(content []byte) (interface{}, error) {
+	var value interface{}
+	if err := yaml.Unmarshal(content, &value); err != nil {
+		return nil, err
+	}
+	return normalizeData(value), nil
+}
+
+func decodeJSONData(content []byte) (interface{}, error) {
+	var value interface{}
+	err := json.Unmarshal(content, &value)
+	return value, err
+}
+
+func decodeTOMLData(content []byte) (interface{}, error) {
+	var value map[string]interface{}
+	err := toml.Unmarshal(content, &value)
+	return value, err
+}
+
+// decodeCSVData returns a map per row, keyed by the header row
+func decodeCSVData(content []byte) (interface{}, error) {
+	records, err := csv.NewReader(strings.NewReader(string(content))).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]map[string]string, 0)
+	if len(records) == 0 {
+		return rows, nil
+	}
+
+	header := records[0]
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(header))
+		for i, column := range header {
+			if i < len(record) {
+				row[column] = record[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// normalizeData converts the maps with non string keys YAML may decode, so
+// the data can be used from templates and hashed as JSON.
+func normalizeData(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeData(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[fmt.Sprint(key)] = normalizeData(item)
+		}
+		return result
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeData(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
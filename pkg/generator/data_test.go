@@ -0,0 +1,105 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadDataDir(t *testing.T) {
+	dir := t.TempDir()
+	writeContent(t, dir, map[string]string{
+		"data/menu.yaml":         "- name: Blog\n  url: /blog/\n- name: About\n  url: /about.html\n",
+		"data/site.json":         `{"twitter": "@gengo", "stars": 10}`,
+		"data/team/members.toml": "[lead]\nname = \"Jane\"\n",
+		"data/products.csv":      "name,price\nBook,10\nPen,2\n",
+		"data/notes.txt":         "ignored",
+		"data/codes.yml":         "1: one\n",
+	})
+
+	data, err := loadDataDir(filepath.Join(dir, "data"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "Blog", "url": "/blog/"},
+		map[string]interface{}{"name": "About", "url": "/about.html"},
+	}, data["menu"])
+	assert.Equal(t, map[string]interface{}{"twitter": "@gengo", "stars": float64(10)}, data["site"])
+	assert.Equal(t, map[string]interface{}{
+		"members": map[string]interface{}{"lead": map[string]interface{}{"name": "Jane"}},
+	}, data["team"])
+	assert.Equal(t, []map[string]string{{"name": "Book", "price": "10"}, {"name": "Pen", "price": "2"}}, data["products"])
+	assert.Equal(t, map[string]interface{}{"1": "one"}, data["codes"])
+	assert.NotContains(t, data, "notes")
+}
+
+func TestLoadDataDir_Missing(t *testing.T) {
+	data, err := loadDataDir(filepath.Join(t.TempDir(), "data"))
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+}
+
+func TestLoadDataDir_Errors(t *testing.T) {
+	dir := t.TempDir()
+	writeContent(t, dir, map[string]string{
+		"data/menu.yaml": "items: [",
+	})
+	_, err := loadDataDir(filepath.Join(dir, "data"))
+	assert.ErrorContains(t, err, "menu.yaml")
+
+	for name, content := range map[string]string{
+		"menu.json": `{"items": [`,
+		"menu.toml": "[items\n",
+		"menu.csv":  "a,b\n\"c\n",
+	} {
+		dir = t.TempDir()
+		writeContent(t, dir, map[string]string{"data/" + name: content})
+		_, err = loadDataDir(filepath.Join(dir, "data"))
+		assert.ErrorContains(t, err, name)
+		assert.NotContains(t, err.Error(), "yaml", name)
+	}
+
+	dir = t.TempDir()
+	writeContent(t, dir, map[string]string{"data/menu.toml": "title = 'Menu'\nitems = [\n"})
+	_, err = loadDataDir(filepath.Join(dir, "data"))
+	var buildErr *BuildError
+	if assert.ErrorAs(t, err, &buildErr) {
+		assert.Equal(t, 2, buildErr.Line)
+	}
+
+	dir = t.TempDir()
+	writeContent(t, dir, map[string]string{
+		"data/menu.yaml": "a: 1",
+		"data/menu.json": `{"a": 1}`,
+	})
+	_, err = loadDataDir(filepath.Join(dir, "data"))
+	assert.ErrorContains(t, err, "defined more than once")
+}
+
+func TestSiteDataInTemplates(t *testing.T) {
+	dir := t.TempDir()
+	writeContent(t, dir, map[string]string{
+		"content/data/team.yaml": "- Jane\n- John\n",
+		"layout.html":            "{{ .HTML }}",
+		"home.html":              "{{ range .Site.Data.team }}{{ . }};{{ end }}",
+	})
+
+	manifest := ManifestFile{
+		DefaultLayoutTemplate: "layout.html",
+		HomeTemplate:          "home.html",
+		DataDir:               "content/data",
+	}
+
+	outDir := t.TempDir()
+	tasks, _, err := scheduleTasks(manifest, dir, outDir, BuildOptions{})
+	assert.NoError(t, err)
+	for _, task := range tasks {
+		assert.NoError(t, task.Execute())
+	}
+
+	content, err := os.ReadFile(filepath.Join(outDir, "index.html"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Jane;John;", string(content))
+}
@@ -0,0 +1,65 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Layouts accepted for dates written in manifests and front matter
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	time.DateOnly,
+}
+
+// parseDate parses a date written in any of the supported layouts
+func parseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q, expected a date like 2006-01-02 or 2006-01-02T15:04:05Z07:00", value)
+}
+
+// Date is a date read from the manifest or the front matter. Any layout
+// accepted by parseDate can be used, and templates can use the time.Time
+// methods, e.g. {{ .PublishedAt.Format "Jan 2, 2006" }}.
+type Date struct {
+	time.Time
+}
+
+func (d *Date) UnmarshalYAML(value *yaml.Node) error {
+	if value.Value == "" {
+		d.Time = time.Time{}
+		return nil
+	}
+
+	t, err := parseDate(value.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", value.Line, err)
+	}
+	d.Time = t
+	return nil
+}
+
+func (d Date) MarshalYAML() (interface{}, error) {
+	return d.String(), nil
+}
+
+// String prints the date omitting the time when it has none
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	if d.Hour() == 0 && d.Minute() == 0 && d.Second() == 0 && d.Nanosecond() == 0 {
+		return d.Format(time.DateOnly)
+	}
+	return d.Format(time.RFC3339)
+}
@@ -0,0 +1,35 @@
+package generator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func mustDate(value string) Date {
+	t, err := parseDate(value)
+	if err != nil {
+		panic(err)
+	}
+	return Date{t}
+}
+
+func TestDate_UnmarshalYAML(t *testing.T) {
+	var page Page
+	err := yaml.Unmarshal([]byte("published-at: 2024-03-01\nlast-edited-at: 2024-03-02 10:30\n"), &page)
+
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), page.PublishedAt.Time)
+	assert.Equal(t, time.Date(2024, 3, 2, 10, 30, 0, 0, time.UTC), page.LastEditedAt.Time)
+	assert.Equal(t, "2024-03-01", page.PublishedAt.String())
+	assert.Equal(t, "2024-03-02T10:30:00Z", page.LastEditedAt.String())
+}
+
+func TestDate_UnmarshalYAMLInvalid(t *testing.T) {
+	var page Page
+	err := yaml.Unmarshal([]byte("title: Post\npublished-at: yesterday\n"), &page)
+
+	assert.ErrorContains(t, err, "line 2")
+}
@@ -0,0 +1,136 @@
+package generator
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// taskDependencies returns the input files and directories a task is
+// generated from, with the paths of pages relative to baseDir. It reports
+// false for tasks whose inputs are not known, which always run on
+// targeted rebuilds.
+func taskDependencies(baseDir string, task Task) ([]string, bool) {
+	switch t := task.(type) {
+	case *PageTask:
+		deps := []string{t.InputFile, t.Template, t.LayoutTemplate}
+		// Navigation links show the title of the neighbour pages
+		for _, page := range []*Page{t.Prev, t.Next} {
+			if page != nil {
+				deps = append(deps, getFullPath(baseDir, page.MarkdownPath))
+			}
+		}
+		deps = append(deps, pageFiles(baseDir, t.Related)...)
+		for _, data := range t.ExternalDataTasks {
+			switch data.Api.kind() {
+			case SourceFile:
+				deps = append(deps, data.Api.File)
+			case SourceGlob:
+				deps = append(deps, globDir(data.Api.Glob))
+			case SourceCommand:
+				// Commands can read anything
+				return nil, false
+			}
+		}
+		return deps, true
+	case *SectionTask:
+		return append([]string{t.Template, t.LayoutTemplate}, pageFiles(baseDir, t.Pages)...), true
+	case *HomeTask:
+		return append([]string{t.Template, t.LayoutTemplate}, pageFiles(baseDir, t.Site.Pages)...), true
+	case *TermTask:
+		return append([]string{t.Template, t.LayoutTemplate}, pageFiles(baseDir, t.Term.Pages)...), true
+	case *TermsTask:
+		deps := []string{t.Template, t.LayoutTemplate}
+		for _, term := range t.Taxonomy.Terms {
+			deps = append(deps, pageFiles(baseDir, term.Pages)...)
+		}
+		return deps, true
+	case *FeedTask:
+		return pageFiles(baseDir, t.Pages), true
+	case *CopyTask:
+		return []string{t.FromPath}, true
+	case *FailedTask:
+		return []string{t.InputFile}, true
+	default:
+		return nil, false
+	}
+}
+
+func pageFiles(baseDir string, pages []Page) []string {
+	files := make([]string, 0, len(pages))
+	for _, page := range pages {
+		files = append(files, getFullPath(baseDir, page.MarkdownPath))
+	}
+	return files
+}
+
+// affectedTasks returns the tasks that have to run again after the changed
+// paths were modified. It reports false when a change is not an input of
+// any task, e.g. a manifest, a partial, a data file, or a new or deleted
+// page, as then the whole site has to be rebuilt.
+func affectedTasks(baseDir string, tasks []Task, changed []string) ([]Task, bool) {
+	dependencies := make([][]string, len(tasks))
+	for i, task := range tasks {
+		deps, ok := taskDependencies(baseDir, task)
+		if ok && deps == nil {
+			deps = []string{}
+		}
+		dependencies[i] = deps
+	}
+
+	affected := make([]bool, len(tasks))
+
+	for _, change := range changed {
+		change = absolutePath(change)
+		known := false
+
+		for i, deps := range dependencies {
+			for _, dep := range deps {
+				if dep != "" && isWithinPath(change, absolutePath(dep)) {
+					affected[i] = true
+					known = true
+				}
+			}
+		}
+
+		if !known {
+			return tasks, false
+		}
+	}
+
+	selected := make([]Task, 0)
+	for i, task := range tasks {
+		// Tasks with unknown inputs always run
+		if affected[i] || dependencies[i] == nil {
+			selected = append(selected, task)
+		}
+	}
+	return selected, true
+}
+
+func isWithinPath(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+func absolutePath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	return abs
+}
+
+// outputUrl returns the url a generated file is served at, index.html
+// files are served at the url of their directory
+func outputUrl(outputDir, file string) string {
+	rel, err := filepath.Rel(outputDir, file)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+
+	url := path.Join("/", filepath.ToSlash(rel))
+	if path.Base(url) == "index.html" {
+		url = strings.TrimSuffix(path.Dir(url), "/") + "/"
+	}
+	return url
+}
@@ -0,0 +1,144 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func prepareDependencySite(t *testing.T) (string, string) {
+	dir := t.TempDir()
+	writeContent(t, dir, map[string]string{
+		"gengo.yaml": `
+base-url: https://example.com
+default-layout-template: layout.html
+default-page-template: page.html
+default-section-template: section.html
+related:
+  disabled: true
+static-assets:
+  - path: static
+    destination: static
+sections:
+  blog:
+    content-dir: blog
+    sort-by: path
+  notes:
+    content-dir: notes
+`,
+		"layout.html":    "{{ .HTML }}",
+		"page.html":      "{{ .Page.Title }}",
+		"section.html":   "{{ range .Pages }}{{ .Title }}{{ end }}",
+		"blog/first.md":  "# First\n",
+		"blog/second.md": "# Second\n",
+		"blog/third.md":  "# Third\n",
+		"notes/note.md":  "# Note\n",
+		"static/app.css": "body {}",
+	})
+	return filepath.Join(dir, "gengo.yaml"), filepath.Join(dir, "output")
+}
+
+func affectedNames(t *testing.T, manifestPath, outDir string, changed ...string) ([]string, bool) {
+	manifest, err := getManifest([]string{manifestPath})
+	assert.NoError(t, err)
+
+	baseDir := filepath.Dir(manifestPath)
+	tasks, _, err := scheduleTasks(manifest, baseDir, outDir, BuildOptions{})
+	assert.NoError(t, err)
+
+	for i := range changed {
+		changed[i] = filepath.Join(baseDir, changed[i])
+	}
+
+	affected, partial := affectedTasks(baseDir, tasks, changed)
+	names := make([]string, 0, len(affected))
+	for _, task := range affected {
+		rel, _ := filepath.Rel(outDir, task.Name())
+		names = append(names, filepath.ToSlash(rel))
+	}
+	sort.Strings(names)
+	return names, partial
+}
+
+func TestAffectedTasks(t *testing.T) {
+	manifestPath, outDir := prepareDependencySite(t)
+
+	// The page, its neighbours, its section listing and the tasks with
+	// unknown inputs
+	names, partial := affectedNames(t, manifestPath, outDir, "blog/first.md")
+	assert.True(t, partial)
+	assert.Equal(t, []string{"blog/first.html", "blog/index.html", "blog/second.html", "robots.txt", "sitemap.xml"}, names)
+
+	names, partial = affectedNames(t, manifestPath, outDir, "static/app.css")
+	assert.True(t, partial)
+	assert.Equal(t, []string{"robots.txt", "sitemap.xml", "static"}, names)
+
+	names, partial = affectedNames(t, manifestPath, outDir, "page.html")
+	assert.True(t, partial)
+	assert.Contains(t, names, "notes/note.html")
+	assert.NotContains(t, names, "notes/index.html")
+
+	// Changes that are not an input of any task rebuild everything
+	_, partial = affectedNames(t, manifestPath, outDir, "gengo.yaml")
+	assert.False(t, partial)
+	_, partial = affectedNames(t, manifestPath, outDir, "blog/deleted.md")
+	assert.False(t, partial)
+}
+
+func TestRebuildSiteAsync_OnlyAffectedFiles(t *testing.T) {
+	manifestPath, outDir := prepareDependencySite(t)
+	collectStatuses(t, manifestPath, outDir, BuildOptions{})
+
+	note := filepath.Join(filepath.Dir(manifestPath), "notes", "note.md")
+	assert.NoError(t, os.WriteFile(note, []byte("# Note\n\nChanged.\n"), 0644))
+
+	files, ch, inputs, err := RebuildSiteAsync([]string{manifestPath}, outDir, BuildOptions{}, []string{note})
+	assert.NoError(t, err)
+
+	urls := make(map[string]FileStatus)
+	for progress := range ch {
+		urls[progress.Url] = progress.Status
+	}
+	assert.Len(t, files, 4)
+	// The inputs of the tasks that did not run are still watched
+	assert.Contains(t, inputs, filepath.Join(filepath.Dir(manifestPath), "blog", "first.md"))
+	assert.Equal(t, Completed, urls["/notes/note.html"])
+	assert.Equal(t, Completed, urls["/notes/"])
+	assert.NotContains(t, urls, "/blog/first.html")
+
+	// The outputs of the tasks that did not run are still cached
+	assert.Contains(t, loadBuildCache(filepath.Dir(manifestPath), outDir).Tasks, filepath.Join(outDir, "blog", "first.html"))
+}
+
+func TestRebuildSiteAsync_MetadataChangeRebuildsEverything(t *testing.T) {
+	manifestPath, outDir := prepareDependencySite(t)
+	collectStatuses(t, manifestPath, outDir, BuildOptions{})
+
+	// Every page can list the title of the others
+	note := filepath.Join(filepath.Dir(manifestPath), "notes", "note.md")
+	assert.NoError(t, os.WriteFile(note, []byte("---\ntitle: Renamed\n---\n# Note\n"), 0644))
+
+	files, ch, _, err := RebuildSiteAsync([]string{manifestPath}, outDir, BuildOptions{}, []string{note})
+	assert.NoError(t, err)
+
+	urls := make(map[string]FileStatus)
+	for progress := range ch {
+		urls[progress.Url] = progress.Status
+	}
+	assert.Len(t, files, len(urls))
+	assert.Equal(t, Completed, urls["/notes/note.html"])
+	assert.Equal(t, Completed, urls["/blog/first.html"])
+	assert.Equal(t, Completed, urls["/blog/"])
+}
+
+func TestOutputUrl(t *testing.T) {
+	out := filepath.Join("site", "output")
+	assert.Equal(t, "/", outputUrl(out, filepath.Join(out, "index.html")))
+	assert.Equal(t, "/blog/", outputUrl(out, filepath.Join(out, "blog", "index.html")))
+	assert.Equal(t, "/blog/post.html", outputUrl(out, filepath.Join(out, "blog", "post.html")))
+	assert.Equal(t, "/static/app.css", outputUrl(out, filepath.Join(out, "static", "app.css")))
+	assert.Equal(t, "", outputUrl(out, filepath.Join("site", "other.html")))
+}
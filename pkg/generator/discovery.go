@@ -0,0 +1,149 @@
+package generator
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Files discovered when a section does not configure include globs
+var defaultIncludeGlobs = []string{"**/*.md", "**/*.html"}
+
+// sectionPages returns the pages of a section. When the section has a
+// content directory, every matching file in it becomes a page. Pages listed
+// explicitly in the manifest override the discovered page with the same
+// path and are listed first. Every page keeps the directory it belongs to,
+// which decides whether it is a page bundle.
+func sectionPages(baseDir string, section Section) ([]Page, error) {
+	if section.ContentDir == "" {
+		return section.Pages, nil
+	}
+
+	discovered, err := discoverPages(baseDir, section)
+	if err != nil {
+		return nil, err
+	}
+
+	explicit := make(map[string]bool)
+	for _, page := range section.Pages {
+		explicit[filepath.Clean(page.MarkdownPath)] = true
+	}
+
+	pages := make([]Page, 0, len(section.Pages)+len(discovered))
+	for _, page := range section.Pages {
+		page.contentDir = section.ContentDir
+		pages = append(pages, page)
+	}
+
+	for _, page := range discovered {
+		if !explicit[filepath.Clean(page.MarkdownPath)] {
+			pages = append(pages, page)
+		}
+	}
+
+	return pages, nil
+}
+
+// discoverPages walks the content directory of a section and returns a page
+// for every markdown or html file matching the include globs and none of
+// the exclude globs. Paths of the pages are relative to baseDir, like the
+// ones written in the manifest.
+func discoverPages(baseDir string, section Section) ([]Page, error) {
+	contentDir := getFullPath(baseDir, section.ContentDir)
+
+	include := section.Include
+	if len(include) == 0 {
+		include = defaultIncludeGlobs
+	}
+
+	paths := make([]string, 0)
+
+	err := filepath.WalkDir(contentDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		ext := filepath.Ext(p)
+		if ext != ".md" && ext != ".html" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(contentDir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if matchesAnyGlob(include, rel) && !matchesAnyGlob(section.Exclude, rel) {
+			paths = append(paths, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fileError(contentDir, err)
+	}
+
+	sort.Strings(paths)
+
+	pages := make([]Page, 0, len(paths))
+	for _, rel := range paths {
+		pages = append(pages, Page{
+			MarkdownPath: filepath.Join(section.ContentDir, filepath.FromSlash(rel)),
+			contentDir:   section.ContentDir,
+		})
+	}
+
+	return pages, nil
+}
+
+func matchesAnyGlob(globs []string, rel string) bool {
+	for _, glob := range globs {
+		if matchGlob(glob, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches a slash separated relative path against a glob. Besides
+// the path.Match syntax, "**" matches any number of directories. Globs
+// without a slash are matched against the file name only, so "*.md"
+// matches markdown files at any depth.
+func matchGlob(glob, rel string) bool {
+	glob = filepath.ToSlash(glob)
+
+	if !strings.Contains(glob, "/") {
+		matched, _ := path.Match(glob, path.Base(rel))
+		return matched
+	}
+
+	return matchSegments(strings.Split(glob, "/"), strings.Split(rel, "/"))
+}
+
+func matchSegments(glob, segments []string) bool {
+	if len(glob) == 0 {
+		return len(segments) == 0
+	}
+
+	if glob[0] == "**" {
+		// Try to match the rest of the glob at every depth
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(glob[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+
+	matched, _ := path.Match(glob[0], segments[0])
+	return matched && matchSegments(glob[1:], segments[1:])
+}
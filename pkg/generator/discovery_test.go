@@ -0,0 +1,84 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchGlob(t *testing.T) {
+	cases := []struct {
+		glob     string
+		rel      string
+		expected bool
+	}{
+		{"*.md", "post.md", true},
+		{"*.md", "2024/post.md", true},
+		{"*.md", "post.html", false},
+		{"drafts/*", "drafts/post.md", true},
+		{"drafts/*", "2024/drafts/post.md", false},
+		{"**/drafts/*", "2024/drafts/post.md", true},
+		{"**/*.md", "post.md", true},
+		{"2024/**", "2024/03/post.md", true},
+		{"2024/**", "2023/post.md", false},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, matchGlob(c.glob, c.rel), "%s ~ %s", c.glob, c.rel)
+	}
+}
+
+func writeContent(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func TestSectionPages_DiscoversContentDir(t *testing.T) {
+	baseDir := t.TempDir()
+	writeContent(t, baseDir, map[string]string{
+		"posts/first.md":        "# First",
+		"posts/2024/second.md":  "# Second",
+		"posts/drafts/draft.md": "# Draft",
+		"posts/about.html":      "<h1>About</h1>",
+		"posts/image.png":       "",
+	})
+
+	pages, err := sectionPages(baseDir, Section{
+		ContentDir: "posts",
+		Exclude:    []string{"drafts/**"},
+		Pages: []Page{
+			{Title: "Explicit", MarkdownPath: "posts/first.md"},
+		},
+	})
+
+	assert.NoError(t, err)
+	paths := make([]string, len(pages))
+	for i, page := range pages {
+		paths[i] = page.MarkdownPath
+	}
+	assert.Equal(t, []string{
+		filepath.Join("posts", "first.md"),
+		filepath.Join("posts", "2024", "second.md"),
+		filepath.Join("posts", "about.html"),
+	}, paths)
+	assert.Equal(t, "Explicit", pages[0].Title)
+}
+
+func TestRenderPage_TitleFromFirstH1(t *testing.T) {
+	baseDir := t.TempDir()
+	writeContent(t, baseDir, map[string]string{
+		"post.md":   "Intro\n\n# From Heading\n",
+		"titled.md": "---\ntitle: From Front Matter\n---\n# From Heading\n",
+	})
+
+	page := loadTestPage(t, baseDir, "post.md")
+	assert.Equal(t, "From Heading", page.Title)
+
+	page = loadTestPage(t, baseDir, "titled.md")
+	assert.Equal(t, "From Front Matter", page.Title)
+}
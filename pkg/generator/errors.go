@@ -0,0 +1,122 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// BuildError is returned by tasks when a file cannot be generated. It keeps
+// enough context to point the user to the source of the problem.
+type BuildError struct {
+	File     string // Input file being generated
+	Template string // Template being parsed or executed, if any
+	Line     int    // 0 when unknown
+	Column   int    // 0 when unknown
+	Err      error
+}
+
+func (e *BuildError) Error() string {
+	var b strings.Builder
+
+	b.WriteString(e.File)
+
+	if e.Template != "" {
+		if e.File != "" {
+			b.WriteString(" ")
+		}
+		b.WriteString("(template ")
+		b.WriteString(e.Template)
+		b.WriteString(e.position())
+		b.WriteString(")")
+	} else {
+		b.WriteString(e.position())
+	}
+
+	if b.Len() > 0 {
+		b.WriteString(": ")
+	}
+	b.WriteString(e.Err.Error())
+
+	return b.String()
+}
+
+func (e *BuildError) Unwrap() error {
+	return e.Err
+}
+
+func (e *BuildError) position() string {
+	if e.Line == 0 {
+		return ""
+	}
+	if e.Column == 0 {
+		return fmt.Sprintf(":%d", e.Line)
+	}
+	return fmt.Sprintf(":%d:%d", e.Line, e.Column)
+}
+
+// Go templates report errors as "template: name:line:col: message"
+var templatePositionRegex = regexp.MustCompile(`template: [^:]+:(\d+)(?::(\d+))?:`)
+
+// yaml.v3 reports errors as "yaml: line N: message"
+var yamlPositionRegex = regexp.MustCompile(`line (\d+):`)
+
+// fileError wraps err with the file that caused it. Errors that already
+// are a BuildError are returned untouched to keep the original context.
+func fileError(file string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var buildErr *BuildError
+	if errors.As(err, &buildErr) {
+		return err
+	}
+
+	return &BuildError{File: file, Err: err}
+}
+
+// templateError wraps an error coming from parsing or executing a template
+func templateError(file, templatePath string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	line, column := findPosition(templatePositionRegex, err.Error())
+
+	return &BuildError{
+		File:     file,
+		Template: templatePath,
+		Line:     line,
+		Column:   column,
+		Err:      err,
+	}
+}
+
+// yamlError wraps an error coming from parsing a YAML file
+func yamlError(file string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	line, _ := findPosition(yamlPositionRegex, err.Error())
+
+	return &BuildError{File: file, Line: line, Err: err}
+}
+
+func findPosition(regex *regexp.Regexp, message string) (int, int) {
+	match := regex.FindStringSubmatch(message)
+	if match == nil {
+		return 0, 0
+	}
+
+	line, _ := strconv.Atoi(match[1])
+	column := 0
+	if len(match) > 2 && match[2] != "" {
+		column, _ = strconv.Atoi(match[2])
+	}
+
+	return line, column
+}
@@ -0,0 +1,71 @@
+package generator
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateError_ExtractsPosition(t *testing.T) {
+	err := templateError("post.md", "page.html", errors.New(`template: page.html:12:5: executing "page.html" at <.Missing>: nil`))
+
+	var buildErr *BuildError
+	assert.True(t, errors.As(err, &buildErr))
+	assert.Equal(t, 12, buildErr.Line)
+	assert.Equal(t, 5, buildErr.Column)
+	assert.Contains(t, err.Error(), "post.md (template page.html:12:5): ")
+}
+
+func TestYamlError_ExtractsLine(t *testing.T) {
+	err := yamlError("gengo.yaml", errors.New("yaml: line 3: mapping values are not allowed in this context"))
+
+	var buildErr *BuildError
+	assert.True(t, errors.As(err, &buildErr))
+	assert.Equal(t, 3, buildErr.Line)
+	assert.Equal(t, 0, buildErr.Column)
+	assert.Contains(t, err.Error(), "gengo.yaml:3: ")
+}
+
+func TestFileError_KeepsExistingContext(t *testing.T) {
+	original := templateError("post.md", "page.html", errors.New("boom"))
+	assert.Same(t, original, fileError("other.md", original))
+	assert.Nil(t, fileError("other.md", nil))
+}
+
+func TestGenerateSiteAsync_ReportsAllFailures(t *testing.T) {
+	manifestPath, outputDir := prepareSimpleBlog(t)
+
+	pageTemplate := filepath.Join(filepath.Dir(manifestPath), "page.html")
+	err := os.WriteFile(pageTemplate, []byte("<p>{{ .HTML }</p>\n"), 0644)
+	assert.NoError(t, err)
+
+	files, ch, err := GenerateSiteAsync([]string{manifestPath}, outputDir, BuildOptions{})
+	assert.NoError(t, err)
+
+	report := NewBuildReport(len(files))
+	for progress := range ch {
+		report.Add(progress)
+	}
+
+	assert.Len(t, report.Failures, 1)
+	failure := report.Failures[0]
+	assert.Equal(t, filepath.Join(outputDir, "blog", "blog1.html"), failure.Filename)
+
+	var buildErr *BuildError
+	assert.True(t, errors.As(failure.Err, &buildErr))
+	assert.Equal(t, pageTemplate, buildErr.Template)
+	assert.Equal(t, 1, buildErr.Line)
+	assert.Error(t, report.Err())
+}
+
+func TestGenerateSiteAsync_InvalidManifest(t *testing.T) {
+	manifestPath := filepath.Join(t.TempDir(), "gengo.yaml")
+	err := os.WriteFile(manifestPath, []byte("title: [unclosed\n"), 0644)
+	assert.NoError(t, err)
+
+	_, _, err = GenerateSiteAsync([]string{manifestPath}, t.TempDir(), BuildOptions{})
+	assert.Error(t, err)
+}
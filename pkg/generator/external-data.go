@@ -0,0 +1,459 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Formats the external data can be decoded from
+const (
+	DataFormatHTML = "html"
+	DataFormatText = "text"
+	DataFormatJSON = "json"
+	DataFormatYAML = "yaml"
+)
+
+// Kinds of external data sources
+const (
+	SourceUrl     = "url"
+	SourceFile    = "file"
+	SourceGlob    = "glob"
+	SourceCommand = "command"
+)
+
+// Policies applied when the external data cannot be fetched
+const (
+	OnErrorFail  = "fail"  // Fail the page
+	OnErrorWarn  = "warn"  // Log a warning and render the page without the data
+	OnErrorStale = "stale" // Use the last response cached on disk, failing without it
+)
+
+const defaultFetchTimeout = 30 * time.Second
+
+// Delay before retrying a failed fetch, multiplied by the attempt number
+var retryDelay = 500 * time.Millisecond
+
+// externalDataCacheDir returns the directory the fetched responses are
+// cached in, so sites can be built offline or with a failing api. It is
+// next to the manifest, as everything in the output directory is served.
+func externalDataCacheDir(baseDir string) string {
+	return filepath.Join(baseDir, cacheDirName, "external")
+}
+
+// kind returns the kind of the source, or "" when none or several are set
+func (api ExternalApi) kind() string {
+	kinds := map[string]string{
+		SourceUrl:     api.Url,
+		SourceFile:    api.File,
+		SourceGlob:    api.Glob,
+		SourceCommand: api.Command,
+	}
+
+	kind := ""
+	for name, value := range kinds {
+		if value == "" {
+			continue
+		}
+		if kind != "" {
+			return ""
+		}
+		kind = name
+	}
+	return kind
+}
+
+// resolve makes the paths of local sources relative to baseDir, the
+// directory of the manifest.
+func (api ExternalApi) resolve(baseDir string) ExternalApi {
+	switch api.kind() {
+	case SourceFile:
+		api.File = getFullPath(baseDir, api.File)
+	case SourceGlob:
+		api.Glob = getFullPath(baseDir, api.Glob)
+	case SourceCommand:
+		api.Dir = firstNonEmpty(getFullPath(baseDir, api.Dir), baseDir)
+	}
+	return api
+}
+
+func (api ExternalApi) method() string {
+	if api.Method == "" {
+		return http.MethodGet
+	}
+	return strings.ToUpper(api.Method)
+}
+
+func (api ExternalApi) format() string {
+	if api.Format == "" {
+		return DataFormatHTML
+	}
+	return api.Format
+}
+
+func (api ExternalApi) onError() string {
+	if api.OnError == "" {
+		return OnErrorFail
+	}
+	return api.OnError
+}
+
+func (api ExternalApi) timeout() time.Duration {
+	timeout, err := time.ParseDuration(api.Timeout)
+	if err != nil || timeout <= 0 {
+		return defaultFetchTimeout
+	}
+	return timeout
+}
+
+// validate checks the configuration when scheduling, before anything is
+// fetched.
+func (api ExternalApi) validate() error {
+	if api.kind() == "" {
+		return fmt.Errorf("exactly one of url, file, glob or command is required")
+	}
+
+	switch api.format() {
+	case DataFormatHTML, DataFormatText, DataFormatJSON, DataFormatYAML:
+	default:
+		return fmt.Errorf("invalid format %q, expected html, text, json or yaml", api.Format)
+	}
+
+	switch api.onError() {
+	case OnErrorFail, OnErrorWarn, OnErrorStale:
+	default:
+		return fmt.Errorf("invalid on-error %q, expected fail, warn or stale", api.OnError)
+	}
+
+	for name, value := range map[string]string{"timeout": api.Timeout, "cache-ttl": api.CacheTTL} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(value); err != nil {
+			return fmt.Errorf("invalid %s %q: %w", name, value, err)
+		}
+	}
+
+	if api.Retries < 0 {
+		return fmt.Errorf("retries cannot be negative")
+	}
+
+	return nil
+}
+
+// key identifies the source configuration, so pages using it share a fetch
+func (api ExternalApi) key() string {
+	data, _ := json.Marshal(api)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// requestKey identifies the request of the source, so that changing how it
+// is decoded, cached or how failures are handled keeps the cached response
+func (api ExternalApi) requestKey() string {
+	data, _ := json.Marshal([]interface{}{api.kind(), api.method(), api.Url, api.File, api.Command, api.Dir, api.Headers, api.Body})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// DataFetcher fetches the external data of a build. Each source is fetched
+// once, however many pages use it.
+type DataFetcher struct {
+	cacheDir string
+
+	mu      sync.Mutex
+	results map[string]*fetchResult
+}
+
+type fetchResult struct {
+	once  sync.Once
+	value interface{}
+	err   error
+}
+
+// newDataFetcher returns a fetcher caching the responses in cacheDir. An
+// empty cacheDir disables the disk cache.
+func newDataFetcher(cacheDir string) *DataFetcher {
+	return &DataFetcher{
+		cacheDir: cacheDir,
+		results:  make(map[string]*fetchResult),
+	}
+}
+
+// Fetch returns the decoded data of the source, applying its failure
+// policy. A nil fetcher fetches without any cache.
+func (f *DataFetcher) Fetch(name string, api ExternalApi) (interface{}, error) {
+	if f == nil {
+		f = newDataFetcher("")
+	}
+
+	f.mu.Lock()
+	result, ok := f.results[api.key()]
+	if !ok {
+		result = &fetchResult{}
+		f.results[api.key()] = result
+	}
+	f.mu.Unlock()
+
+	result.once.Do(func() {
+		result.value, result.err = f.fetch(name, api)
+	})
+
+	return result.value, result.err
+}
+
+func (f *DataFetcher) fetch(name string, api ExternalApi) (interface{}, error) {
+	if api.kind() == SourceGlob {
+		data, err := loadGlobData(api)
+		if err != nil {
+			return failedFetch(name, api, err, nil)
+		}
+		return data, nil
+	}
+
+	cached := f.readCache(api)
+
+	if cached != nil && api.CacheTTL != "" {
+		ttl, err := time.ParseDuration(api.CacheTTL)
+		if err == nil && time.Since(cached.FetchedAt) < ttl {
+			return decodeExternalData(api.format(), cached.Body)
+		}
+	}
+
+	body, err := loadExternalData(api)
+	if err == nil {
+		f.writeCache(api, body)
+		return decodeExternalData(api.format(), body)
+	}
+
+	return failedFetch(name, api, err, cached)
+}
+
+// failedFetch applies the failure policy of the source. cached is nil when
+// there is no cached response.
+func failedFetch(name string, api ExternalApi, err error, cached *cachedResponse) (interface{}, error) {
+	err = fmt.Errorf("external data %s: %w", name, err)
+
+	switch api.onError() {
+	case OnErrorStale:
+		if cached != nil {
+			log.Printf("%v, using the response cached at %s", err, cached.FetchedAt.Format(time.RFC3339))
+			return decodeExternalData(api.format(), cached.Body)
+		}
+		return nil, fmt.Errorf("%w, and there is no cached response", err)
+	case OnErrorWarn:
+		log.Printf("warning: %v", err)
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
+type cachedResponse struct {
+	FetchedAt time.Time `json:"fetched-at"`
+	Body      []byte    `json:"body"`
+}
+
+func (f *DataFetcher) cachePath(api ExternalApi) string {
+	return filepath.Join(f.cacheDir, api.requestKey()+".json")
+}
+
+func (f *DataFetcher) readCache(api ExternalApi) *cachedResponse {
+	if f.cacheDir == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(f.cachePath(api))
+	if err != nil {
+		return nil
+	}
+
+	var cached cachedResponse
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil
+	}
+	return &cached
+}
+
+func (f *DataFetcher) writeCache(api ExternalApi, body []byte) {
+	if f.cacheDir == "" {
+		return
+	}
+
+	data, err := json.Marshal(cachedResponse{FetchedAt: time.Now(), Body: body})
+	if err == nil {
+		err = saveFile(data, f.cachePath(api))
+	}
+	if err != nil {
+		log.Printf("failed to cache external data: %v", err)
+	}
+}
+
+// loadExternalData reads the raw data of the source, retrying network
+// errors, server errors and failed commands.
+func loadExternalData(api ExternalApi) ([]byte, error) {
+	var err error
+	for attempt := 0; attempt <= api.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * retryDelay)
+		}
+
+		var body []byte
+		var retry bool
+		switch api.kind() {
+		case SourceFile:
+			body, err = os.ReadFile(api.File)
+		case SourceCommand:
+			body, retry, err = runCommand(api)
+		default:
+			body, retry, err = fetchUrl(api)
+		}
+		if err == nil || !retry {
+			return body, err
+		}
+	}
+	return nil, err
+}
+
+// runCommand runs the command of the source and returns its stdout. The
+// error includes stderr, which usually explains the failure.
+func runCommand(api ExternalApi) ([]byte, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), api.timeout())
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", api.Command)
+	cmd.Dir = api.Dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			err = fmt.Errorf("%w: %s", err, message)
+		}
+		return nil, true, fmt.Errorf("command %q: %w", api.Command, err)
+	}
+	return stdout.Bytes(), false, nil
+}
+
+// loadGlobData decodes every file matching the pattern of the source. Each
+// file is listed, sorted by path, as a map with its name, its file name and
+// its decoded content.
+func loadGlobData(api ExternalApi) ([]map[string]interface{}, error) {
+	paths, err := filepath.Glob(api.Glob)
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob %q: %w", api.Glob, err)
+	}
+	sort.Strings(paths)
+
+	files := make([]map[string]interface{}, 0, len(paths))
+	for _, path := range paths {
+		body, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := decodeExternalData(api.format(), body)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		file := filepath.Base(path)
+		files = append(files, map[string]interface{}{
+			"name":    strings.TrimSuffix(file, filepath.Ext(file)),
+			"file":    file,
+			"content": content,
+		})
+	}
+	return files, nil
+}
+
+// fetchUrl does the request, reporting whether a failure is worth a retry
+func fetchUrl(api ExternalApi) ([]byte, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), api.timeout())
+	defer cancel()
+
+	var body io.Reader
+	if api.Body != "" {
+		body = bytes.NewBufferString(os.ExpandEnv(api.Body))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, api.method(), os.ExpandEnv(api.Url), body)
+	if err != nil {
+		return nil, false, err
+	}
+	for name, value := range api.Headers {
+		// Tokens are read from the environment, e.g. "Bearer ${API_TOKEN}"
+		req.Header.Set(name, os.ExpandEnv(value))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return nil, retry, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, true, err
+	}
+	return data, false, nil
+}
+
+// decodeExternalData decodes the response into the data the templates get
+func decodeExternalData(format string, body []byte) (interface{}, error) {
+	switch format {
+	case DataFormatJSON:
+		return decodeJSONData(body)
+	case DataFormatYAML:
+		return decodeYAMLData(body)
+	case DataFormatText:
+		return string(body), nil
+	default:
+		return template.HTML(body), nil
+	}
+}
+
+// pageExternalData maps the external data of the page to the sources of the
+// manifest, sorted by key so the page fingerprint is stable.
+func pageExternalData(manifest ManifestFile, baseDir string, page Page) ([]ExternalDataTask, error) {
+	keys := make([]string, 0, len(page.ExternalData))
+	for key := range page.ExternalData {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	tasks := make([]ExternalDataTask, 0, len(keys))
+	for _, key := range keys {
+		source := page.ExternalData[key].Source
+		api, ok := manifest.ExternalData[source]
+		if !ok {
+			return nil, fmt.Errorf("external data %s: unknown source %q", key, source)
+		}
+		if err := api.validate(); err != nil {
+			return nil, fmt.Errorf("external data source %s: %w", source, err)
+		}
+
+		tasks = append(tasks, ExternalDataTask{Key: key, Source: source, Api: api.resolve(baseDir)})
+	}
+	return tasks, nil
+}
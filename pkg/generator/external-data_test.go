@@ -0,0 +1,205 @@
+package generator
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingServer responds with the given statuses in order, repeating the
+// last one, and counts the requests.
+func countingServer(t *testing.T, body string, statuses ...int) (*httptest.Server, *int32) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		status := statuses[min(int(n), len(statuses))-1]
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestDataFetcher_DecodesFormats(t *testing.T) {
+	t.Setenv("TEST_API_TOKEN", "secret")
+
+	var authorization, method string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		method = r.Method
+		fmt.Fprint(w, `{"name": "gengo", "stars": [1, 2]}`)
+	}))
+	defer server.Close()
+
+	fetcher := newDataFetcher(t.TempDir())
+
+	data, err := fetcher.Fetch("repo", ExternalApi{
+		Url:     server.URL,
+		Method:  "post",
+		Headers: map[string]string{"Authorization": "Bearer ${TEST_API_TOKEN}"},
+		Format:  DataFormatJSON,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "gengo", "stars": []interface{}{float64(1), float64(2)}}, data)
+	assert.Equal(t, "Bearer secret", authorization)
+	assert.Equal(t, http.MethodPost, method)
+
+	data, err = fetcher.Fetch("repo", ExternalApi{Url: server.URL, Format: DataFormatYAML})
+	assert.NoError(t, err)
+	assert.Equal(t, "gengo", data.(map[string]interface{})["name"])
+
+	data, err = fetcher.Fetch("repo", ExternalApi{Url: server.URL, Format: DataFormatText})
+	assert.NoError(t, err)
+	assert.IsType(t, "", data)
+
+	data, err = fetcher.Fetch("repo", ExternalApi{Url: server.URL})
+	assert.NoError(t, err)
+	assert.IsType(t, template.HTML(""), data)
+}
+
+func TestDataFetcher_FetchesOncePerBuild(t *testing.T) {
+	server, requests := countingServer(t, "data", http.StatusOK)
+	api := ExternalApi{Url: server.URL}
+
+	fetcher := newDataFetcher("")
+	for i := 0; i < 3; i++ {
+		_, err := fetcher.Fetch("source", api)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+
+	_, err := newDataFetcher("").Fetch("source", api)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(requests))
+}
+
+func TestDataFetcher_Retries(t *testing.T) {
+	defer func(delay time.Duration) { retryDelay = delay }(retryDelay)
+	retryDelay = 0
+
+	server, requests := countingServer(t, "ok", http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK)
+	data, err := newDataFetcher("").Fetch("source", ExternalApi{Url: server.URL, Retries: 2, OnError: OnErrorFail})
+	assert.NoError(t, err)
+	assert.Equal(t, template.HTML("ok"), data)
+	assert.Equal(t, int32(3), atomic.LoadInt32(requests))
+
+	// Client errors are not retried
+	server, requests = countingServer(t, "", http.StatusNotFound)
+	_, err = newDataFetcher("").Fetch("source", ExternalApi{Url: server.URL, Retries: 2, OnError: OnErrorFail})
+	assert.ErrorContains(t, err, "404")
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+}
+
+func TestDataFetcher_OnError(t *testing.T) {
+	cacheDir := t.TempDir()
+	server, _ := countingServer(t, "fresh", http.StatusOK, http.StatusInternalServerError)
+	api := ExternalApi{Url: server.URL, Format: DataFormatText}
+
+	// The first build caches the response on disk
+	_, err := newDataFetcher(cacheDir).Fetch("source", api)
+	assert.NoError(t, err)
+
+	// Failing is the default
+	_, err = newDataFetcher(cacheDir).Fetch("source", api)
+	assert.ErrorContains(t, err, "external data source")
+
+	api.OnError = OnErrorFail
+	_, err = newDataFetcher(cacheDir).Fetch("source", api)
+	assert.ErrorContains(t, err, "external data source")
+
+	api.OnError = OnErrorWarn
+	data, err := newDataFetcher(cacheDir).Fetch("source", api)
+	assert.NoError(t, err)
+	assert.Nil(t, data)
+
+	api.OnError = OnErrorStale
+	data, err = newDataFetcher(cacheDir).Fetch("source", api)
+	assert.NoError(t, err)
+	assert.Equal(t, "fresh", data)
+
+	_, err = newDataFetcher(t.TempDir()).Fetch("source", api)
+	assert.ErrorContains(t, err, "no cached response")
+}
+
+func TestDataFetcher_CacheTTL(t *testing.T) {
+	cacheDir := t.TempDir()
+	server, requests := countingServer(t, "data", http.StatusOK)
+	api := ExternalApi{Url: server.URL, CacheTTL: "1h"}
+
+	for i := 0; i < 2; i++ {
+		_, err := newDataFetcher(cacheDir).Fetch("source", api)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+}
+
+func TestDataFetcher_LocalSources(t *testing.T) {
+	baseDir := t.TempDir()
+	writeContent(t, baseDir, map[string]string{
+		"data/stats.json":       `{"users": 3}`,
+		"commands/generate.txt": "gengo generate",
+		"commands/serve.txt":    "gengo serve",
+	})
+	fetcher := newDataFetcher(t.TempDir())
+
+	data, err := fetcher.Fetch("stats", ExternalApi{File: "data/stats.json", Format: DataFormatJSON}.resolve(baseDir))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"users": float64(3)}, data)
+
+	data, err = fetcher.Fetch("commands", ExternalApi{Glob: "commands/*.txt", Format: DataFormatText}.resolve(baseDir))
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"name": "generate", "file": "generate.txt", "content": "gengo generate"},
+		{"name": "serve", "file": "serve.txt", "content": "gengo serve"},
+	}, data)
+
+	data, err = fetcher.Fetch("help", ExternalApi{Command: "cat generate.txt", Dir: "commands", Format: DataFormatText}.resolve(baseDir))
+	assert.NoError(t, err)
+	assert.Equal(t, "gengo generate", data)
+
+	_, err = fetcher.Fetch("missing", ExternalApi{File: "data/missing.json", OnError: OnErrorFail}.resolve(baseDir))
+	assert.ErrorContains(t, err, "missing.json")
+}
+
+func TestDataFetcher_CommandFailure(t *testing.T) {
+	api := ExternalApi{Command: "echo broken >&2; exit 3", OnError: OnErrorFail}.resolve(t.TempDir())
+
+	_, err := newDataFetcher("").Fetch("help", api)
+	assert.ErrorContains(t, err, "exit status 3: broken")
+}
+
+func TestExternalApiValidate(t *testing.T) {
+	assert.NoError(t, ExternalApi{Url: "https://example.com", Timeout: "5s", CacheTTL: "1h"}.validate())
+	assert.NoError(t, ExternalApi{Command: "gengo --help", Format: DataFormatText}.validate())
+	assert.Error(t, ExternalApi{}.validate())
+	assert.Error(t, ExternalApi{Url: "https://example.com", File: "data.json"}.validate())
+	assert.Error(t, ExternalApi{Url: "https://example.com", Format: "xml"}.validate())
+	assert.Error(t, ExternalApi{Url: "https://example.com", OnError: "ignore"}.validate())
+	assert.Error(t, ExternalApi{Url: "https://example.com", Timeout: "soon"}.validate())
+}
+
+func TestPageExternalData(t *testing.T) {
+	manifest := ManifestFile{
+		ExternalData: map[string]ExternalApi{
+			"repo": {Url: "https://example.com/repo"},
+		},
+	}
+
+	tasks, err := pageExternalData(manifest, "", Page{ExternalData: map[string]ExternalDataValue{
+		"stars": {Source: "repo"},
+		"info":  {Source: "repo"},
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"info", "stars"}, []string{tasks[0].Key, tasks[1].Key})
+
+	_, err = pageExternalData(manifest, "", Page{ExternalData: map[string]ExternalDataValue{
+		"stars": {Source: "missing"},
+	}})
+	assert.ErrorContains(t, err, `unknown source "missing"`)
+}
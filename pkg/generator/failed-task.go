@@ -0,0 +1,17 @@
+package generator
+
+// FailedTask reports a page that could not be loaded, e.g. because of an
+// invalid front matter, so the rest of the site is still generated and the
+// error is shown along with the other failed files.
+type FailedTask struct {
+	InputFile string
+	Err       error
+}
+
+func (t *FailedTask) Execute() error {
+	return t.Err
+}
+
+func (t *FailedTask) Name() string {
+	return t.InputFile
+}
@@ -0,0 +1,39 @@
+package generator
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleTasks_BrokenPagesFail(t *testing.T) {
+	manifestPath, outDir := prepareDependencySite(t)
+	baseDir := filepath.Dir(manifestPath)
+	broken := filepath.Join(baseDir, "blog", "second.md")
+	assert.NoError(t, os.WriteFile(broken, []byte("---\ntitle: [unclosed\n---\n# Second\n"), 0644))
+
+	statuses := make(map[string]FileStatus)
+	var failure error
+	_, ch, err := GenerateSiteAsync([]string{manifestPath}, outDir, BuildOptions{})
+	assert.NoError(t, err)
+	for progress := range ch {
+		statuses[progress.Filename] = progress.Status
+		if progress.Status == Failed {
+			failure = progress.Err
+		}
+	}
+
+	// The rest of the site is generated without the broken page
+	assert.Equal(t, Failed, statuses[broken])
+	assert.Equal(t, Completed, statuses[filepath.Join(outDir, "blog", "first.html")])
+	assert.Equal(t, Completed, statuses[filepath.Join(outDir, "blog", "index.html")])
+	assert.NotContains(t, statuses, filepath.Join(outDir, "blog", "second.html"))
+
+	var buildErr *BuildError
+	assert.True(t, errors.As(failure, &buildErr))
+	assert.Equal(t, broken, buildErr.File)
+	assert.Equal(t, 1, buildErr.Line)
+}
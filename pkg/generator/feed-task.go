@@ -0,0 +1,415 @@
+package generator
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	FeedRSS  = "rss"
+	FeedAtom = "atom"
+	FeedJSON = "json"
+)
+
+var defaultFeedFormats = []string{FeedRSS, FeedAtom, FeedJSON}
+
+// Output file name of every feed format
+var feedFileNames = map[string]string{
+	FeedRSS:  "feed.xml",
+	FeedAtom: "atom.xml",
+	FeedJSON: "feed.json",
+}
+
+// FeedTask writes a syndication feed with the pages of a section, or with
+// the pages of every section for site-wide feeds.
+type FeedTask struct {
+	Title      string
+	BaseUrl    string
+	Link       string // Path of the html page listing the feed pages
+	Url        string // Path of the feed itself
+	Format     string
+	OutputFile string
+	Pages      []Page
+	Limit      int
+	// BuildTime dates the feed when no page has a date. It is left out of
+	// the fingerprint, so it does not rewrite the feed on every build.
+	BuildTime time.Time `json:"-"`
+}
+
+type feedItem struct {
+	Page      Page
+	Url       string
+	Published time.Time
+	Updated   time.Time
+	HTML      string
+}
+
+func (t *FeedTask) items() []feedItem {
+	items := make([]feedItem, 0, len(t.Pages))
+
+	for _, page := range t.Pages {
+		item := feedItem{
+			Page:      page,
+			Url:       absoluteUrl(t.BaseUrl, page.Url),
+			Published: page.PublishedAt.Time,
+			Updated:   page.PublishedAt.Time,
+		}
+		if !page.LastEditedAt.IsZero() {
+			item.Updated = page.LastEditedAt.Time
+		}
+		if item.Updated.IsZero() {
+			item.Updated = t.BuildTime
+		}
+
+		items = append(items, item)
+	}
+
+	// Newest first, pages without date at the end
+	sort.SliceStable(items, func(i, j int) bool {
+		if !items[i].Published.Equal(items[j].Published) {
+			return items[i].Published.After(items[j].Published)
+		}
+		return items[i].Url < items[j].Url
+	})
+
+	if t.Limit > 0 && len(items) > t.Limit {
+		items = items[:t.Limit]
+	}
+
+	for i := range items {
+		// Feed readers resolve the links against the feed, not the page
+		items[i].HTML = absoluteLinks(string(items[i].Page.HTML), t.BaseUrl)
+	}
+
+	return items
+}
+
+// lastUpdate returns the most recent date of the items, or the build time
+// when there are no items
+func (t *FeedTask) lastUpdate(items []feedItem) time.Time {
+	if len(items) == 0 {
+		return t.BuildTime
+	}
+
+	latest := time.Time{}
+	for _, item := range items {
+		if item.Updated.After(latest) {
+			latest = item.Updated
+		}
+	}
+	return latest
+}
+
+func (t *FeedTask) Execute() error {
+	items := t.items()
+
+	var content []byte
+	var err error
+
+	switch t.Format {
+	case FeedRSS:
+		content, err = t.renderRSS(items)
+	case FeedAtom:
+		content, err = t.renderAtom(items)
+	case FeedJSON:
+		content, err = t.renderJSON(items)
+	default:
+		err = fmt.Errorf("unknown feed format %q", t.Format)
+	}
+	if err != nil {
+		return fileError(t.OutputFile, err)
+	}
+
+	return fileError(t.OutputFile, saveFile(content, t.OutputFile))
+}
+
+// Fingerprint implements CacheableTask
+func (t *FeedTask) Fingerprint() (string, error) {
+	return hashStrings(hashTask(t), hashPages(t.Pages)), nil
+}
+
+func (t *FeedTask) Name() string {
+	return t.OutputFile
+}
+
+type rssFeed struct {
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	AtomNS    string     `xml:"xmlns:atom,attr"`
+	ContentNS string     `xml:"xmlns:content,attr"`
+	Channel   rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	AtomLink      rssLink   `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Guid        string   `xml:"guid"`
+	PubDate     string   `xml:"pubDate,omitempty"`
+	Description string   `xml:"description,omitempty"`
+	Content     string   `xml:"content:encoded"`
+	Categories  []string `xml:"category"`
+}
+
+func (t *FeedTask) renderRSS(items []feedItem) ([]byte, error) {
+	channel := rssChannel{
+		Title:       t.Title,
+		Link:        absoluteUrl(t.BaseUrl, t.Link),
+		Description: t.Title,
+		AtomLink: rssLink{
+			Href: absoluteUrl(t.BaseUrl, t.Url),
+			Rel:  "self",
+			Type: "application/rss+xml",
+		},
+	}
+
+	if updated := t.lastUpdate(items); !updated.IsZero() {
+		channel.LastBuildDate = updated.Format(time.RFC1123Z)
+	}
+
+	for _, item := range items {
+		rss := rssItem{
+			Title:       item.Page.Title,
+			Link:        item.Url,
+			Guid:        item.Url,
+			Description: item.Page.Description,
+			Content:     item.HTML,
+			Categories:  item.Page.Tags,
+		}
+		if !item.Published.IsZero() {
+			rss.PubDate = item.Published.Format(time.RFC1123Z)
+		}
+		channel.Items = append(channel.Items, rss)
+	}
+
+	return marshalXML(rssFeed{
+		Version:   "2.0",
+		AtomNS:    "http://www.w3.org/2005/Atom",
+		ContentNS: "http://purl.org/rss/1.0/modules/content/",
+		Channel:   channel,
+	})
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Id      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	Id         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published,omitempty"`
+	Link       atomLink       `xml:"link"`
+	Summary    *atomText      `xml:"summary,omitempty"`
+	Content    atomText       `xml:"content"`
+	Categories []atomCategory `xml:"category"`
+}
+
+func (t *FeedTask) renderAtom(items []feedItem) ([]byte, error) {
+	feed := atomFeed{
+		Id:      absoluteUrl(t.BaseUrl, t.Url),
+		Title:   t.Title,
+		Updated: t.lastUpdate(items).Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: absoluteUrl(t.BaseUrl, t.Url), Rel: "self", Type: "application/atom+xml"},
+			{Href: absoluteUrl(t.BaseUrl, t.Link), Rel: "alternate", Type: "text/html"},
+		},
+	}
+
+	for _, item := range items {
+		entry := atomEntry{
+			Id:      item.Url,
+			Title:   item.Page.Title,
+			Updated: item.Updated.Format(time.RFC3339),
+			Link:    atomLink{Href: item.Url, Rel: "alternate"},
+			Content: atomText{Type: "html", Body: item.HTML},
+		}
+		if !item.Published.IsZero() {
+			entry.Published = item.Published.Format(time.RFC3339)
+		}
+		if item.Page.Description != "" {
+			entry.Summary = &atomText{Type: "text", Body: item.Page.Description}
+		}
+		for _, tag := range item.Page.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	return marshalXML(feed)
+}
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageUrl string         `json:"home_page_url"`
+	FeedUrl     string         `json:"feed_url"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	Id            string   `json:"id"`
+	Url           string   `json:"url"`
+	Title         string   `json:"title"`
+	ContentHtml   string   `json:"content_html"`
+	Summary       string   `json:"summary,omitempty"`
+	DatePublished string   `json:"date_published,omitempty"`
+	DateModified  string   `json:"date_modified,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+}
+
+func (t *FeedTask) renderJSON(items []feedItem) ([]byte, error) {
+	feed := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       t.Title,
+		HomePageUrl: absoluteUrl(t.BaseUrl, t.Link),
+		FeedUrl:     absoluteUrl(t.BaseUrl, t.Url),
+		Items:       make([]jsonFeedItem, 0, len(items)),
+	}
+
+	for _, item := range items {
+		jsonItem := jsonFeedItem{
+			Id:          item.Url,
+			Url:         item.Url,
+			Title:       item.Page.Title,
+			ContentHtml: item.HTML,
+			Summary:     item.Page.Description,
+			Tags:        item.Page.Tags,
+		}
+		if !item.Published.IsZero() {
+			jsonItem.DatePublished = item.Published.Format(time.RFC3339)
+		}
+		if edited := item.Page.LastEditedAt.Time; !edited.IsZero() && !edited.Equal(item.Published) {
+			jsonItem.DateModified = edited.Format(time.RFC3339)
+		}
+		feed.Items = append(feed.Items, jsonItem)
+	}
+
+	return json.MarshalIndent(feed, "", "  ")
+}
+
+func marshalXML(v interface{}) ([]byte, error) {
+	content, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), content...), nil
+}
+
+// absoluteUrl joins the site base url with an absolute path
+func absoluteUrl(baseUrl, path string) string {
+	if path == "" {
+		path = "/"
+	}
+	return strings.TrimSuffix(baseUrl, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+// Links of the page html to paths of the site
+var rootRelativeLink = regexp.MustCompile(`\b(href|src)="(/[^"]*)"`)
+
+// absoluteLinks prefixes the links to paths of the site with the base url
+func absoluteLinks(html, baseUrl string) string {
+	return rootRelativeLink.ReplaceAllStringFunc(html, func(link string) string {
+		parts := rootRelativeLink.FindStringSubmatch(link)
+		// Protocol relative urls already name their host
+		if strings.HasPrefix(parts[2], "//") {
+			return link
+		}
+		return parts[1] + `="` + absoluteUrl(baseUrl, parts[2]) + `"`
+	})
+}
+
+// sectionFeedConfig returns the feed configuration of a section, or nil
+// when the section does not generate feeds.
+func sectionFeedConfig(manifest ManifestFile, section Section) *FeedConfig {
+	config := manifest.Feeds
+	if section.Feeds != nil {
+		config = section.Feeds
+	}
+	if config == nil || config.Disabled {
+		return nil
+	}
+	return config
+}
+
+// feedTasks schedules one FeedTask per configured format. dir is the url
+// path of the directory the feeds are written to, e.g. "/blog", or "/" for
+// site-wide feeds.
+func feedTasks(manifest ManifestFile, config *FeedConfig, outDir, dir, title string, pages []Page, buildTime time.Time) ([]Task, error) {
+	if manifest.BaseUrl == "" {
+		return nil, fmt.Errorf("feeds require the base-url of the site to be set in the manifest")
+	}
+
+	formats := config.Formats
+	if len(formats) == 0 {
+		formats = defaultFeedFormats
+	}
+
+	link := path.Join("/", dir)
+	if !strings.HasSuffix(link, "/") {
+		link += "/"
+	}
+
+	tasks := make([]Task, 0, len(formats))
+	for _, format := range formats {
+		fileName, ok := feedFileNames[format]
+		if !ok {
+			return nil, fmt.Errorf("unknown feed format %q, expected one of %s", format, strings.Join(defaultFeedFormats, ", "))
+		}
+
+		url := path.Join("/", dir, fileName)
+		tasks = append(tasks, &FeedTask{
+			Title:      title,
+			BaseUrl:    manifest.BaseUrl,
+			Link:       link,
+			Url:        url,
+			Format:     format,
+			OutputFile: getFullPath(outDir, url),
+			Pages:      pages,
+			Limit:      config.Limit,
+			BuildTime:  buildTime,
+		})
+	}
+
+	return tasks, nil
+}
@@ -0,0 +1,132 @@
+package generator
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestFeedTask(t *testing.T, format string) *FeedTask {
+	return &FeedTask{
+		Title:      "Site - blog",
+		BaseUrl:    "https://example.com/",
+		Link:       "/blog/",
+		Url:        "/blog/" + feedFileNames[format],
+		Format:     format,
+		OutputFile: filepath.Join(t.TempDir(), feedFileNames[format]),
+		Limit:      1,
+		Pages: []Page{
+			{Title: "Old", MarkdownPath: "old.md", Url: "/blog/old.html", PublishedAt: mustDate("2023-01-01"), HTML: "<p>Old post</p>"},
+			{Title: "New", MarkdownPath: "new.md", Url: "/blog/new.html", PublishedAt: mustDate("2024-01-01"), Tags: []string{"go"}, HTML: "<p>New post</p>"},
+		},
+	}
+}
+
+func TestFeedTask_RSS(t *testing.T) {
+	task := newTestFeedTask(t, FeedRSS)
+	assert.NoError(t, task.Execute())
+
+	content, err := os.ReadFile(task.OutputFile)
+	assert.NoError(t, err)
+
+	var feed struct {
+		Channel struct {
+			Items []struct {
+				Title    string `xml:"title"`
+				Link     string `xml:"link"`
+				PubDate  string `xml:"pubDate"`
+				Category string `xml:"category"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	assert.NoError(t, xml.Unmarshal(content, &feed))
+	assert.Contains(t, string(content), "<link>https://example.com/blog/</link>")
+	assert.Len(t, feed.Channel.Items, 1)
+	assert.Equal(t, "New", feed.Channel.Items[0].Title)
+	assert.Equal(t, "https://example.com/blog/new.html", feed.Channel.Items[0].Link)
+	assert.Equal(t, "Mon, 01 Jan 2024 00:00:00 +0000", feed.Channel.Items[0].PubDate)
+	assert.Equal(t, "go", feed.Channel.Items[0].Category)
+}
+
+func TestFeedTask_Atom(t *testing.T) {
+	task := newTestFeedTask(t, FeedAtom)
+	task.Limit = 0
+	assert.NoError(t, task.Execute())
+
+	content, err := os.ReadFile(task.OutputFile)
+	assert.NoError(t, err)
+
+	var feed struct {
+		Updated string `xml:"updated"`
+		Entries []struct {
+			Id      string `xml:"id"`
+			Content string `xml:"content"`
+		} `xml:"entry"`
+	}
+	assert.NoError(t, xml.Unmarshal(content, &feed))
+	assert.Equal(t, "2024-01-01T00:00:00Z", feed.Updated)
+	assert.Len(t, feed.Entries, 2)
+	assert.Equal(t, "https://example.com/blog/new.html", feed.Entries[0].Id)
+	assert.Contains(t, feed.Entries[0].Content, "<p>New post</p>")
+}
+
+func TestFeedTask_JSON(t *testing.T) {
+	task := newTestFeedTask(t, FeedJSON)
+	assert.NoError(t, task.Execute())
+
+	content, err := os.ReadFile(task.OutputFile)
+	assert.NoError(t, err)
+
+	var feed jsonFeed
+	assert.NoError(t, json.Unmarshal(content, &feed))
+	assert.Equal(t, "https://jsonfeed.org/version/1.1", feed.Version)
+	assert.Equal(t, "https://example.com/blog/feed.json", feed.FeedUrl)
+	assert.Len(t, feed.Items, 1)
+	assert.Equal(t, "2024-01-01T00:00:00Z", feed.Items[0].DatePublished)
+	assert.Equal(t, []string{"go"}, feed.Items[0].Tags)
+}
+
+func TestFeedTasks_RequireBaseUrl(t *testing.T) {
+	_, err := feedTasks(ManifestFile{}, &FeedConfig{}, "", "blog", "Blog", nil, time.Time{})
+	assert.Error(t, err)
+
+	_, err = feedTasks(ManifestFile{BaseUrl: "https://example.com"}, &FeedConfig{Formats: []string{"csv"}}, "", "blog", "Blog", nil, time.Time{})
+	assert.Error(t, err)
+
+	tasks, err := feedTasks(ManifestFile{BaseUrl: "https://example.com"}, &FeedConfig{}, "out", "blog", "Blog", nil, time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 3)
+}
+
+func TestFeedTasks_SiteWide(t *testing.T) {
+	buildTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	pages := []Page{{Title: "Undated", MarkdownPath: "undated.md", Url: "/blog/undated.html", HTML: "<p>Undated</p>"}}
+
+	tasks, err := feedTasks(ManifestFile{BaseUrl: "https://example.com"}, &FeedConfig{}, t.TempDir(), "/", "Site", pages, buildTime)
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 3)
+
+	content := make(map[string]string)
+	for _, task := range tasks {
+		feed := task.(*FeedTask)
+		assert.Equal(t, "/", feed.Link)
+		assert.NoError(t, feed.Execute())
+
+		data, err := os.ReadFile(feed.OutputFile)
+		assert.NoError(t, err)
+		content[feed.Format] = string(data)
+	}
+
+	assert.Contains(t, content[FeedRSS], "<link>https://example.com/</link>")
+	assert.Contains(t, content[FeedRSS], "<atom:link href=\"https://example.com/feed.xml\"")
+	assert.Contains(t, content[FeedAtom], `<link href="https://example.com/" rel="alternate" type="text/html">`)
+	assert.Contains(t, content[FeedAtom], "<updated>2024-05-01T12:00:00Z</updated>")
+	assert.NotContains(t, content[FeedAtom], "0001-01-01")
+	assert.Contains(t, content[FeedJSON], `"home_page_url": "https://example.com/"`)
+	assert.NotContains(t, content[FeedJSON], "date_modified")
+}
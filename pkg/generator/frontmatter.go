@@ -0,0 +1,123 @@
+package generator
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+
+	"github.com/saasuke-labs/gengo/pkg/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// applyFrontMatter merges the front matter of a markdown file into the page
+// declared in the manifest. Front matter uses the same keys as the manifest
+// page entries and takes precedence over them:
+//
+//   - scalar values (title, description, published-at, ...) replace the
+//     manifest value
+//   - lists (tags, flags) replace the manifest list
+//   - maps (metadata, external-data) are merged key by key
+//
+// Keys not present in the front matter keep the manifest value. The
+// markdown path can not be changed from the front matter.
+func applyFrontMatter(page Page, frontMatter map[string]interface{}) (Page, error) {
+	if len(frontMatter) == 0 {
+		return page, nil
+	}
+
+	// Re-encode the front matter so that it is decoded with the same yaml
+	// tags used by the manifest. Decoding only overrides the keys present.
+	data, err := yaml.Marshal(frontMatter)
+	if err != nil {
+		return page, err
+	}
+
+	merged := page
+	// Copy the maps, decoding into them would modify the manifest
+	merged.Metadata = merge(page.Metadata, nil)
+	merged.ExternalData = make(map[string]ExternalDataValue)
+	for key, value := range page.ExternalData {
+		merged.ExternalData[key] = value
+	}
+
+	if err := yaml.Unmarshal(data, &merged); err != nil {
+		return page, fmt.Errorf("invalid front matter: %w", err)
+	}
+
+	merged.MarkdownPath = page.MarkdownPath
+
+	return merged, nil
+}
+
+// readPage returns the manifest page merged with the front matter of its
+// markdown file, and the content of the page without the front matter.
+// Html pages have no front matter, their whole content is returned.
+func readPage(baseDir string, page Page) (Page, []byte, error) {
+	ext := filepath.Ext(page.MarkdownPath)
+	if ext != ".md" && ext != ".html" {
+		return page, nil, nil
+	}
+
+	inputFile := getFullPath(baseDir, page.MarkdownPath)
+
+	content, err := os.ReadFile(inputFile)
+	if err != nil {
+		return page, nil, fileError(inputFile, err)
+	}
+	page.ContentHash = hashStrings(string(content))
+
+	if ext == ".html" {
+		return page, content, nil
+	}
+
+	frontMatter, body, err := parser.ParseFrontMatter(content)
+	if err != nil {
+		return page, nil, yamlError(inputFile, err)
+	}
+
+	page, err = applyFrontMatter(page, frontMatter)
+	if err != nil {
+		return page, nil, yamlError(inputFile, err)
+	}
+
+	return page, body, nil
+}
+
+// renderPage sets the html of the page, and the data rendered from its
+// markdown content. When no title is set, the first H1 of the markdown is
+// used. Relative links are resolved against page.Url.
+func renderPage(baseDir string, page Page, body []byte) (Page, error) {
+	switch ext := filepath.Ext(page.MarkdownPath); ext {
+	case "":
+		return page, nil
+	case ".html":
+		page.HTML = template.HTML(body)
+		return page, nil
+	case ".md":
+	default:
+		return page, fileError(getFullPath(baseDir, page.MarkdownPath), fmt.Errorf("unsupported file type %s", ext))
+	}
+
+	rendered, err := parser.RenderMarkdown(body, page.Url)
+	if err != nil {
+		return page, fileError(getFullPath(baseDir, page.MarkdownPath), err)
+	}
+
+	page.HTML = rendered.HTML
+	if page.Title == "" {
+		page.Title = rendered.Title
+	}
+	if page.Summary == "" {
+		page.Summary = rendered.Summary
+		page.Truncated = rendered.Truncated
+	} else {
+		page.Truncated = true
+	}
+	page.Toc = rendered.Toc
+	page.TableOfContents = rendered.TableOfContents
+	page.WordCount = rendered.WordCount
+	page.ReadingTime = rendered.ReadingTime
+
+	return page, nil
+}
@@ -0,0 +1,111 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyFrontMatter_Precedence(t *testing.T) {
+	page := Page{
+		Title:        "Manifest title",
+		Description:  "Manifest description",
+		MarkdownPath: "post.md",
+		Tags:         []string{"manifest"},
+		Metadata:     map[string]string{"author": "manifest", "lang": "en"},
+	}
+
+	merged, err := applyFrontMatter(page, map[string]interface{}{
+		"title":         "Front matter title",
+		"markdown-path": "other.md",
+		"tags":          []interface{}{"go", "web"},
+		"metadata":      map[string]interface{}{"author": "front matter", "year": 2024},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Front matter title", merged.Title)
+	assert.Equal(t, "Manifest description", merged.Description)
+	assert.Equal(t, "post.md", merged.MarkdownPath)
+	assert.Equal(t, []string{"go", "web"}, merged.Tags)
+	assert.Equal(t, map[string]string{"author": "front matter", "lang": "en", "year": "2024"}, merged.Metadata)
+
+	// The manifest page is not modified
+	assert.Equal(t, "manifest", page.Metadata["author"])
+}
+
+// loadTestPage reads and renders a page of baseDir, like scheduleTasks
+func loadTestPage(t *testing.T, baseDir, markdownPath string) Page {
+	page, body, err := readPage(baseDir, Page{MarkdownPath: markdownPath})
+	assert.NoError(t, err)
+	page, err = renderPage(baseDir, page, body)
+	assert.NoError(t, err)
+	return page
+}
+
+func TestReadPage_ReadsFrontMatter(t *testing.T) {
+	baseDir := t.TempDir()
+	content := "---\ntitle: Hello\npublished-at: 2024-03-01\n---\n# Heading\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(baseDir, "post.md"), []byte(content), 0644))
+
+	page, body, err := readPage(baseDir, Page{MarkdownPath: "post.md"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello", page.Title)
+	assert.Equal(t, "2024-03-01", page.PublishedAt.String())
+	assert.Equal(t, "# Heading\n", string(body))
+}
+
+func TestReadPage_InvalidFrontMatter(t *testing.T) {
+	baseDir := t.TempDir()
+	content := "---\ntags: not-a-list: [\n---\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(baseDir, "post.md"), []byte(content), 0644))
+
+	_, _, err := readPage(baseDir, Page{MarkdownPath: "post.md"})
+	assert.Error(t, err)
+}
+
+func TestRenderPage_RendersContentData(t *testing.T) {
+	baseDir := t.TempDir()
+	writeContent(t, baseDir, map[string]string{
+		"post.md":    "# Heading\n\nIntro.\n\n<!--more-->\n\n## Details\n\nMore text.\n",
+		"summary.md": "---\nsummary: Custom summary\n---\n# Heading\n\nText.\n",
+	})
+
+	page := loadTestPage(t, baseDir, "post.md")
+	assert.Equal(t, "Heading", page.Title)
+	assert.Contains(t, string(page.Summary), "Intro.")
+	assert.True(t, page.Truncated)
+	assert.Equal(t, "details", page.Toc[0].ID)
+	assert.Equal(t, 5, page.WordCount)
+	assert.Equal(t, 1, page.ReadingTime)
+
+	page = loadTestPage(t, baseDir, "summary.md")
+	assert.Equal(t, "Custom summary", string(page.Summary))
+}
+
+func TestPageTask_PassesPageToTemplate(t *testing.T) {
+	baseDir := t.TempDir()
+	writeContent(t, baseDir, map[string]string{
+		"post.md":     "# Heading\n\n## Part\n\nText.\n",
+		"page.html":   "{{ .Title }}|{{ .Page.ReadingTime }}|{{ .Page.TableOfContents }}",
+		"layout.html": "{{ .Title }}:{{ .Page.Title }}:{{ .HTML }}",
+	})
+
+	page := loadTestPage(t, baseDir, "post.md")
+
+	task := &PageTask{
+		Title:          "Site",
+		InputFile:      filepath.Join(baseDir, "post.md"),
+		OutputFile:     filepath.Join(baseDir, "out", "post.html"),
+		Template:       filepath.Join(baseDir, "page.html"),
+		LayoutTemplate: filepath.Join(baseDir, "layout.html"),
+		Page:           page,
+	}
+	assert.NoError(t, task.Execute())
+
+	content, err := os.ReadFile(task.OutputFile)
+	assert.NoError(t, err)
+	assert.Equal(t, `Site:Heading:Heading|1|<nav class="toc"><ul><li><a href="#part">Part</a></li></ul></nav>`, string(content))
+}
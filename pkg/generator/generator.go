@@ -2,6 +2,8 @@ package generator
 
 import (
 	"fmt"
+	"log"
+	"os"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -14,17 +16,46 @@ const (
 	Started   FileStatus = "started"
 	Completed FileStatus = "completed"
 	Failed    FileStatus = "failed"
+	Skipped   FileStatus = "skipped"
 )
 
+// BuildOptions controls how the site is generated
+type BuildOptions struct {
+	// NoCache ignores the build cache and regenerates every file
+	NoCache bool
+	// Drafts includes pages marked as draft
+	Drafts bool
+	// Future includes pages with a published-at date in the future
+	Future bool
+	// Expired includes pages with an expires-at date in the past
+	Expired bool
+}
+
 // FileProgress represents a progress update for a file
 type FileProgress struct {
 	Filename string
+	Url      string // Url the file is served at
 	Status   FileStatus
+	Err      error // Set when Status is Failed
 }
 
-func GenerateSiteAsync(manifestPaths []string, outputDir string) ([]FileProgress, <-chan FileProgress) {
+func GenerateSiteAsync(manifestPaths []string, outputDir string, opts BuildOptions) ([]FileProgress, <-chan FileProgress, error) {
+	files, ch, _, err := RebuildSiteAsync(manifestPaths, outputDir, opts, nil)
+	return files, ch, err
+}
 
-	manifest := getManifest(manifestPaths)
+// RebuildSiteAsync regenerates only the files affected by the changed input
+// paths, according to the dependencies of every task. When a change can
+// not be traced to specific files, changes the site shared by every page,
+// e.g. the title or tags of a page, or changed is nil, the whole site is
+// generated. It also returns the dependencies of every task of the site,
+// including the ones that do not run, to be watched through InputPaths.
+func RebuildSiteAsync(manifestPaths []string, outputDir string, opts BuildOptions, changed []string) ([]FileProgress, <-chan FileProgress, []string, error) {
+
+	manifest, err := getManifest(manifestPaths)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 
 	// TODO - See this
 	baseDir := filepath.Dir(manifestPaths[0])
@@ -32,18 +63,52 @@ func GenerateSiteAsync(manifestPaths []string, outputDir string) ([]FileProgress
 	fmt.Println("Generating site...", manifest)
 	progressCh := make(chan FileProgress)
 
-	tasks := scheduleTasks(manifest, baseDir, outputDir)
+	tasks, site, err := scheduleTasks(manifest, baseDir, outputDir, opts)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	inputs := taskInputs(baseDir, tasks)
+	stored := loadBuildCache(baseDir, outputDir)
+
+	// Every page can show the titles, dates or tags of the others, a change
+	// in the site rebuilds all of them
+	partial := false
+	if changed != nil && stored.Site == site.fingerprint() {
+		tasks, partial = affectedTasks(baseDir, tasks, changed)
+	}
 
 	files := make([]FileProgress, len(tasks))
 	for idx, task := range tasks {
 
 		files[idx] = FileProgress{
 			Filename: task.Name(),
+			Url:      outputUrl(outputDir, task.Name()),
 			Status:   Pending,
 		}
 	}
 
+	// Fingerprints are checked against the previous build and recorded into
+	// a fresh cache, so outputs that are no longer generated are dropped.
+	previous := newBuildCache(baseDir, outputDir)
+	if !opts.NoCache {
+		previous = stored
+	}
+	cache := newBuildCache(baseDir, outputDir)
+	cache.Site = site.fingerprint()
+	if partial {
+		// Outputs of the tasks that do not run are still up to date
+		for output, fingerprint := range stored.Tasks {
+			cache.Record(output, fingerprint)
+		}
+	}
+	manifestHash := hashManifests(manifestPaths)
+
 	go func() {
+		if !partial {
+			removeStaleOutputs(outputDir, stored, tasks)
+		}
+
 		var wg sync.WaitGroup
 
 		for _, task := range tasks {
@@ -51,24 +116,84 @@ func GenerateSiteAsync(manifestPaths []string, outputDir string) ([]FileProgress
 			go func(task Task) {
 				defer wg.Done()
 
-				progressCh <- FileProgress{Filename: task.Name(), Status: Started}
+				url := outputUrl(outputDir, task.Name())
+				progressCh <- FileProgress{Filename: task.Name(), Url: url, Status: Started}
+
+				fingerprint := ""
+				if cacheable, ok := task.(CacheableTask); ok {
+					hash, err := cacheable.Fingerprint()
+					if err == nil {
+						fingerprint = hashStrings(manifestHash, hash)
+					}
+				}
+
+				if fingerprint != "" && previous.IsFresh(task.Name(), fingerprint) {
+					cache.Record(task.Name(), fingerprint)
+					progressCh <- FileProgress{Filename: task.Name(), Url: url, Status: Skipped}
+					return
+				}
 
 				err := task.Execute()
 
 				if err == nil {
-					progressCh <- FileProgress{Filename: task.Name(), Status: Completed}
+					if fingerprint != "" {
+						cache.Record(task.Name(), fingerprint)
+					}
+					progressCh <- FileProgress{Filename: task.Name(), Url: url, Status: Completed}
 				} else {
-					progressCh <- FileProgress{Filename: task.Name(), Status: Failed}
+					progressCh <- FileProgress{Filename: task.Name(), Url: url, Status: Failed, Err: err}
 				}
 
 			}(task)
 		}
 
 		wg.Wait()
+
+		if err := cache.Save(); err != nil {
+			log.Printf("failed to save build cache: %v", err)
+		}
+
 		close(progressCh)
 	}()
 
-	return files, progressCh
+	return files, progressCh, inputs, nil
+}
+
+// removeStaleOutputs deletes the outputs of the previous build that no task
+// generates anymore, such as deleted pages or pages that became drafts,
+// expired or scheduled for the future. Only paths inside outputDir are
+// removed, along with the directories left empty. Nothing is removed while
+// a page is broken.
+func removeStaleOutputs(outputDir string, previous *BuildCache, tasks []Task) {
+	root := absolutePath(outputDir)
+
+	generated := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		// The outputs of a broken page are not known, the last good ones
+		// keep being served until it is fixed
+		if _, ok := task.(*FailedTask); ok {
+			return
+		}
+		generated[absolutePath(task.Name())] = true
+	}
+
+	for output := range previous.Tasks {
+		path := absolutePath(output)
+		if generated[path] || path == root || !isWithinPath(path, root) {
+			continue
+		}
+
+		if err := os.RemoveAll(path); err != nil {
+			log.Printf("failed to remove %s: %v", output, err)
+			continue
+		}
+		for dir := filepath.Dir(path); dir != root && isWithinPath(dir, root); dir = filepath.Dir(dir) {
+			// Fails for directories that are not empty
+			if os.Remove(dir) != nil {
+				break
+			}
+		}
+	}
 }
 
 func slugify(s string) string {
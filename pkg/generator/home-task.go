@@ -1,11 +1,8 @@
 package generator
 
-import (
-	"bytes"
-	"html/template"
-)
-
 type HomeData struct {
+	Taxonomies map[string]Taxonomy
+	Site       *Site
 }
 
 type HomeTask struct {
@@ -15,27 +12,32 @@ type HomeTask struct {
 	Template       string
 	LayoutTemplate string
 	Metadata       map[string]string
+	Site           *Site             `json:"-"`
+	Templates      *TemplateRegistry `json:"-"`
 }
 
 func (t HomeTask) Execute() error {
-	// TODO - See error from save page or generating the html
-	html := bytes.NewBufferString("")
-	tmpl := template.Must(template.ParseFiles(t.Template))
-
-	tmpl.Execute(html, HomeData{})
-
-	html2 := applyTemplate(t.LayoutTemplate, PageData{
-		Title:    t.Title,
-		HTML:     template.HTML(html.String()),
-		Sections: t.Sections,
-		Section:  "",
-		Metadata: t.Metadata,
+	return t.Templates.savePageTo(t.OutputFile, t.LayoutTemplate, t.Template, HomeData{Site: t.Site, Taxonomies: t.Site.taxonomies()}, PageData{
+		Title:      t.Title,
+		Sections:   t.Sections,
+		Section:    "",
+		Metadata:   t.Metadata,
+		Taxonomies: t.Site.taxonomies(),
+		Site:       t.Site,
 	})
+}
 
-	savePage(html2, t.OutputFile)
-
-	return nil
-
+// Fingerprint implements CacheableTask
+func (t HomeTask) Fingerprint() (string, error) {
+	files, err := hashFiles(t.Template, t.LayoutTemplate)
+	if err != nil {
+		return "", err
+	}
+	partials, err := t.Templates.Hash()
+	if err != nil {
+		return "", err
+	}
+	return hashStrings(hashTask(t), t.Site.fingerprint(), hashPages(t.Site.pages()), files, partials), nil
 }
 
 func (t HomeTask) Name() string {
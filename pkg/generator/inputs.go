@@ -0,0 +1,111 @@
+package generator
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// InputPaths returns the files and directories the site is generated from:
+// the manifests, templates, partials, data files, pages, content
+// directories, static assets and local external data, along with the
+// dependencies of the tasks returned by the last build, such as the
+// templates a page sets in its front matter. Watching them is enough to
+// know when the site has to be rebuilt. Paths are relative to the working
+// directory, like the manifest paths.
+func InputPaths(manifestPaths []string, dependencies []string) ([]string, error) {
+	manifest, err := getManifest(manifestPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	baseDir := filepath.Dir(manifestPaths[0])
+
+	paths := make([]string, 0)
+	paths = append(paths, manifestPaths...)
+	paths = append(paths, partialsDir(manifest, baseDir), dataDir(manifest, baseDir))
+
+	relative := []string{
+		manifest.DefaultLayoutTemplate,
+		manifest.DefaultPageTemplate,
+		manifest.DefaultSectionTemplate,
+		manifest.HomeTemplate,
+	}
+
+	for _, section := range manifest.Sections {
+		relative = append(relative, section.Template, section.PageTemplate, section.ContentDir)
+		for _, page := range section.Pages {
+			relative = append(relative, page.MarkdownPath, page.Template, page.Layout)
+			if isBundle(page) {
+				relative = append(relative, filepath.Dir(page.MarkdownPath))
+			}
+		}
+	}
+
+	for _, taxonomy := range manifest.Taxonomies {
+		relative = append(relative, taxonomy.Template, taxonomy.IndexTemplate)
+	}
+
+	for _, asset := range manifest.StaticAssets {
+		relative = append(relative, asset.Path)
+	}
+
+	for _, api := range manifest.ExternalData {
+		switch api.kind() {
+		case SourceFile:
+			relative = append(relative, api.File)
+		case SourceGlob:
+			relative = append(relative, globDir(api.Glob))
+		}
+	}
+
+	for _, path := range relative {
+		if path != "" {
+			paths = append(paths, getFullPath(baseDir, path))
+		}
+	}
+
+	paths = append(paths, dependencies...)
+
+	return uniquePaths(paths), nil
+}
+
+// taskInputs returns the dependencies of the tasks
+func taskInputs(baseDir string, tasks []Task) []string {
+	inputs := make([]string, 0)
+	for _, task := range tasks {
+		deps, _ := taskDependencies(baseDir, task)
+		for _, dep := range deps {
+			if dep != "" {
+				inputs = append(inputs, dep)
+			}
+		}
+	}
+	return uniquePaths(inputs)
+}
+
+// globDir returns the directory a glob matches files in, the part of the
+// pattern before the first wildcard.
+func globDir(glob string) string {
+	dir := filepath.Dir(glob)
+	for strings.ContainsAny(dir, "*?[") {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
+
+func uniquePaths(paths []string) []string {
+	seen := make(map[string]bool)
+	unique := make([]string, 0, len(paths))
+
+	for _, path := range paths {
+		path = filepath.Clean(path)
+		if !seen[path] {
+			seen[path] = true
+			unique = append(unique, path)
+		}
+	}
+
+	sort.Strings(unique)
+	return unique
+}
@@ -0,0 +1,88 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInputPaths(t *testing.T) {
+	dir := t.TempDir()
+	manifestPath := filepath.Join(dir, "gengo.yaml")
+	manifest := `
+default-layout-template: layouts/layout.html
+default-page-template: layouts/page.html
+home-template: layouts/home.html
+sections:
+  blog:
+    content-dir: posts
+    template: layouts/blog.html
+  docs:
+    pages:
+      - markdown-path: docs/intro.md
+      - markdown-path: docs/guide/index.md
+static-assets:
+  - path: static
+    destination: static
+external-data:
+  stats:
+    file: data/stats.json
+  examples:
+    glob: examples/*/*.yaml
+  help:
+    command: gengo --help
+`
+	assert.NoError(t, os.WriteFile(manifestPath, []byte(manifest), 0644))
+
+	paths, err := InputPaths([]string{manifestPath}, nil)
+	assert.NoError(t, err)
+
+	expected := []string{
+		"data",
+		"data/stats.json",
+		"docs/guide",
+		"docs/guide/index.md",
+		"docs/intro.md",
+		"examples",
+		"gengo.yaml",
+		"layouts/blog.html",
+		"layouts/home.html",
+		"layouts/layout.html",
+		"layouts/page.html",
+		"partials",
+		"posts",
+		"static",
+	}
+	for i, path := range expected {
+		expected[i] = filepath.Join(dir, path)
+	}
+	assert.Equal(t, expected, paths)
+}
+
+func TestInputPaths_FrontMatterTemplates(t *testing.T) {
+	manifestPath, outDir := prepareDependencySite(t)
+	dir := filepath.Dir(manifestPath)
+	writeContent(t, dir, map[string]string{
+		"blog/first.md":       "---\ntemplate: layouts/custom.html\nlayout: layouts/bare.html\n---\n# First\n",
+		"layouts/custom.html": "{{ .Page.Title }}",
+		"layouts/bare.html":   "{{ .HTML }}",
+	})
+
+	_, ch, inputs, err := RebuildSiteAsync([]string{manifestPath}, outDir, BuildOptions{}, nil)
+	assert.NoError(t, err)
+	for range ch {
+	}
+
+	paths, err := InputPaths([]string{manifestPath}, inputs)
+	assert.NoError(t, err)
+	assert.Contains(t, paths, filepath.Join(dir, "layouts", "custom.html"))
+	assert.Contains(t, paths, filepath.Join(dir, "layouts", "bare.html"))
+}
+
+func TestGlobDir(t *testing.T) {
+	assert.Equal(t, "examples", globDir("examples/*.yaml"))
+	assert.Equal(t, "examples", globDir("examples/*/*.yaml"))
+	assert.Equal(t, ".", globDir("*.yaml"))
+}
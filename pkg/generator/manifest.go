@@ -1,9 +1,12 @@
 package generator
 
 import (
+	"fmt"
+	"html/template"
 	"log"
 	"os"
 
+	"github.com/saasuke-labs/gengo/pkg/parser"
 	"gopkg.in/yaml.v3"
 )
 
@@ -15,17 +18,44 @@ type Page struct {
 	Title        string            `yaml:"title"`
 	Description  string            `yaml:"description"`
 	MarkdownPath string            `yaml:"markdown-path"`
-	PublishedAt  string            `yaml:"published-at"`
-	LastEditedAt string            `yaml:"last-edited-at"`
+	PublishedAt  Date              `yaml:"published-at"`
+	LastEditedAt Date              `yaml:"last-edited-at"`
+	ExpiresAt    Date              `yaml:"expires-at"`
+	Draft        bool              `yaml:"draft"`
+	Weight       int               `yaml:"weight"`
 	Tags         []string          `yaml:"tags"`
 	Flags        []string          `yaml:"flags"`
 	Metadata     map[string]string `yaml:"metadata"`
 	Section      string
 	ExternalData map[string]ExternalDataValue `yaml:"external-data"`
-}
-
-func (p *Page) OutFileName() string {
-	return convertExtension(p.MarkdownPath, ".html")
+	// Taxonomies holds the terms of the taxonomies other than tags, e.g.
+	// categories: [go, testing]
+	Taxonomies map[string][]string `yaml:"taxonomies"`
+	// Template and Layout override the templates of the section and site
+	Template string `yaml:"template"`
+	Layout   string `yaml:"layout"`
+	// Slug replaces :slug in the permalink, defaults to the file name
+	Slug string `yaml:"slug"`
+	// Summary defaults to the content before <!--more-->, or to the
+	// beginning of the text
+	Summary template.HTML `yaml:"summary" json:"-"`
+	// Url is the path of the generated page, set when scheduling
+	Url string `yaml:"-"`
+	// contentDir is the directory the page belongs to, the content
+	// directory of its section or the manifest directory. Only index pages
+	// in a subdirectory of it are page bundles.
+	contentDir string
+	// Set from the content when loading the page. They are left out of the
+	// fingerprints, which hash the content itself through ContentHash.
+	HTML            template.HTML     `yaml:"-" json:"-"`
+	ContentHash     string            `yaml:"-" json:"-"`
+	Truncated       bool              `yaml:"-" json:"-"`
+	Toc             []parser.TocEntry `yaml:"-" json:"-"`
+	TableOfContents template.HTML     `yaml:"-" json:"-"`
+	WordCount       int               `yaml:"-" json:"-"`
+	ReadingTime     int               `yaml:"-" json:"-"`
+	// Resources are the files of a page bundle, copied next to the page
+	Resources []Resource `yaml:"-"`
 }
 
 type Section struct {
@@ -33,6 +63,54 @@ type Section struct {
 	PageTemplate string            `yaml:"page-template"`
 	Pages        []Page            `yaml:"pages"`
 	Metadata     map[string]string `yaml:"metadata"`
+	// ContentDir makes every markdown and html file inside it a page of the
+	// section. Include and Exclude are globs relative to ContentDir.
+	ContentDir string   `yaml:"content-dir"`
+	Include    []string `yaml:"include"`
+	Exclude    []string `yaml:"exclude"`
+	// Feeds overrides the site feed configuration for this section
+	Feeds *FeedConfig `yaml:"feeds"`
+	// Paginate splits the section and tag listings in pages of this size
+	Paginate int `yaml:"paginate"`
+	// SortBy orders the pages of the section, e.g. "published-at desc"
+	SortBy string `yaml:"sort-by"`
+	// Permalink is the url pattern of the pages, e.g. "/:section/:year/:slug/"
+	Permalink string `yaml:"permalink"`
+}
+
+// FeedConfig configures the syndication feeds generated for the sections
+type FeedConfig struct {
+	Disabled bool     `yaml:"disabled"`
+	Formats  []string `yaml:"formats"`   // rss, atom and/or json. Defaults to all of them
+	Limit    int      `yaml:"limit"`     // Maximum number of items, 0 means no limit
+	SiteWide bool     `yaml:"site-wide"` // Also generate feeds with the pages of every section
+}
+
+// SitemapConfig configures sitemap.xml, generated when base-url is set
+type SitemapConfig struct {
+	Disabled bool `yaml:"disabled"`
+}
+
+// RobotsConfig configures robots.txt, generated when base-url is set
+type RobotsConfig struct {
+	Disabled bool     `yaml:"disabled"`
+	Disallow []string `yaml:"disallow"` // Paths crawlers should not visit
+	Content  string   `yaml:"content"`  // Replaces the generated content
+}
+
+// TaxonomyConfig configures a taxonomy, a way of grouping pages by terms
+// such as tags, categories or authors
+type TaxonomyConfig struct {
+	Template      string `yaml:"template"`       // Template of the term pages. Defaults to the section template
+	IndexTemplate string `yaml:"index-template"` // Template of the page listing every term
+	SiteWide      bool   `yaml:"site-wide"`      // Also generate term pages with the pages of every section
+	Paginate      int    `yaml:"paginate"`       // Page size of the site-wide term pages
+}
+
+// RelatedConfig configures the related pages computed for every page
+type RelatedConfig struct {
+	Disabled bool `yaml:"disabled"`
+	Limit    int  `yaml:"limit"` // Defaults to 5
 }
 
 type StaticAsset struct {
@@ -40,20 +118,49 @@ type StaticAsset struct {
 	Destination string `yaml:"destination"`
 }
 
+// ExternalApi is a source of external data. Exactly one of Url, File, Glob
+// or Command is set.
 type ExternalApi struct {
-	Url string `yaml:"url"`
+	Url     string            `yaml:"url"`
+	File    string            `yaml:"file"`    // Path relative to the manifest
+	Glob    string            `yaml:"glob"`    // Pattern relative to the manifest, e.g. "data/*.json"
+	Command string            `yaml:"command"` // Run with sh, its stdout is the data
+	Dir     string            `yaml:"dir"`     // Working directory of the command, defaults to the manifest directory
+	Method  string            `yaml:"method"`  // Defaults to GET
+	Headers map[string]string `yaml:"headers"` // Environment variables are expanded, e.g. "Bearer ${API_TOKEN}"
+	Body    string            `yaml:"body"`
+	Timeout string            `yaml:"timeout"` // e.g. "10s", defaults to 30s
+	Retries int               `yaml:"retries"` // Retries of network errors, server errors and failed commands
+	Format  string            `yaml:"format"`  // html, text, json or yaml. Defaults to html
+	// CacheTTL reuses the response cached on disk while it is younger than
+	// this duration, e.g. "1h", instead of fetching it again
+	CacheTTL string `yaml:"cache-ttl"`
+	OnError  string `yaml:"on-error"` // fail, warn or stale. Defaults to fail
 }
 
 type ManifestFile struct {
 	Title                  string                 `yaml:"title"`
+	BaseUrl                string                 `yaml:"base-url"`
 	DefaultLayoutTemplate  string                 `yaml:"default-layout-template"`
 	DefaultPageTemplate    string                 `yaml:"default-page-template"`
 	DefaultSectionTemplate string                 `yaml:"default-section-template"`
+	PartialsDir            string                 `yaml:"partials-dir"`
+	DataDir                string                 `yaml:"data-dir"`
 	Metadata               map[string]string      `yaml:"metadata"`
 	HomeTemplate           string                 `yaml:"home-template"`
 	Sections               map[string]Section     `yaml:"sections"`
 	StaticAssets           []StaticAsset          `yaml:"static-assets"`
 	ExternalData           map[string]ExternalApi `yaml:"external-data"`
+	Feeds                  *FeedConfig            `yaml:"feeds"`
+	Sitemap                *SitemapConfig         `yaml:"sitemap"`
+	Robots                 *RobotsConfig          `yaml:"robots"`
+	Related                *RelatedConfig         `yaml:"related"`
+	// Taxonomies defaults to tags when not set
+	Taxonomies map[string]TaxonomyConfig `yaml:"taxonomies"`
+	// Permalink is the url pattern of the pages of every section that does
+	// not set its own. PrettyUrls defaults it to "/:section/:slug/".
+	Permalink  string `yaml:"permalink"`
+	PrettyUrls bool   `yaml:"pretty-urls"`
 }
 
 func mergeManifest(manifest1, manifest2 ManifestFile) ManifestFile {
@@ -64,6 +171,26 @@ func mergeManifest(manifest1, manifest2 ManifestFile) ManifestFile {
 		merged.Title = manifest2.Title
 	}
 
+	if manifest2.BaseUrl != "" {
+		merged.BaseUrl = manifest2.BaseUrl
+	}
+
+	if manifest2.Feeds != nil {
+		merged.Feeds = manifest2.Feeds
+	}
+
+	if manifest2.Sitemap != nil {
+		merged.Sitemap = manifest2.Sitemap
+	}
+
+	if manifest2.Robots != nil {
+		merged.Robots = manifest2.Robots
+	}
+
+	if manifest2.Related != nil {
+		merged.Related = manifest2.Related
+	}
+
 	merged.Metadata = merge(manifest1.Metadata, manifest2.Metadata)
 
 	if manifest2.DefaultLayoutTemplate != "" {
@@ -76,9 +203,19 @@ func mergeManifest(manifest1, manifest2 ManifestFile) ManifestFile {
 	if manifest2.DefaultSectionTemplate != "" {
 		merged.DefaultSectionTemplate = manifest2.DefaultSectionTemplate
 	}
+	if manifest2.PartialsDir != "" {
+		merged.PartialsDir = manifest2.PartialsDir
+	}
+	if manifest2.DataDir != "" {
+		merged.DataDir = manifest2.DataDir
+	}
 	if manifest2.HomeTemplate != "" {
 		merged.HomeTemplate = manifest2.HomeTemplate
 	}
+	if manifest2.Permalink != "" {
+		merged.Permalink = manifest2.Permalink
+	}
+	merged.PrettyUrls = manifest1.PrettyUrls || manifest2.PrettyUrls
 
 	if manifest2.Sections != nil {
 		if merged.Sections == nil {
@@ -91,6 +228,17 @@ func mergeManifest(manifest1, manifest2 ManifestFile) ManifestFile {
 		}
 	}
 
+	if manifest2.Taxonomies != nil {
+		if merged.Taxonomies == nil {
+			merged.Taxonomies = make(map[string]TaxonomyConfig)
+		}
+		for name, taxonomy := range manifest2.Taxonomies {
+			if _, exists := merged.Taxonomies[name]; !exists {
+				merged.Taxonomies[name] = taxonomy
+			}
+		}
+	}
+
 	if manifest2.StaticAssets != nil {
 		if merged.StaticAssets == nil {
 			merged.StaticAssets = make([]StaticAsset, 0)
@@ -113,26 +261,36 @@ func mergeManifest(manifest1, manifest2 ManifestFile) ManifestFile {
 	return merged
 }
 
-func getManifest(manifestPaths []string) ManifestFile {
+func getManifest(manifestPaths []string) (ManifestFile, error) {
+	if len(manifestPaths) == 0 {
+		return ManifestFile{}, fmt.Errorf("no manifest file provided")
+	}
+
 	// Read the manifest files and merge them
-	var mergedManifest ManifestFile = getManifestFile(manifestPaths[0])
+	mergedManifest, err := getManifestFile(manifestPaths[0])
+	if err != nil {
+		return ManifestFile{}, err
+	}
 	for _, manifestPath := range manifestPaths[1:] {
-		manifest := getManifestFile(manifestPath)
+		manifest, err := getManifestFile(manifestPath)
+		if err != nil {
+			return ManifestFile{}, err
+		}
 		mergedManifest = mergeManifest(mergedManifest, manifest)
 	}
-	return mergedManifest
+	return mergedManifest, nil
 }
 
-func getManifestFile(manifestPath string) ManifestFile {
+func getManifestFile(manifestPath string) (ManifestFile, error) {
 	log.Printf("Reading manifest file: %s", manifestPath)
 	data, err := os.ReadFile(manifestPath)
 	if err != nil {
-		log.Fatalf("failed to read manifest file: %v", err)
+		return ManifestFile{}, fileError(manifestPath, fmt.Errorf("failed to read manifest file: %w", err))
 	}
 	var manifest ManifestFile
 	if err := yaml.Unmarshal(data, &manifest); err != nil {
-		log.Fatalf("failed to parse YAML: %v", err)
+		return ManifestFile{}, yamlError(manifestPath, fmt.Errorf("failed to parse YAML: %w", err))
 	}
 
-	return manifest
+	return manifest, nil
 }
@@ -0,0 +1,96 @@
+package generator
+
+import (
+	"sort"
+)
+
+// Number of related pages when the manifest does not configure it
+const defaultRelatedLimit = 5
+
+// Shared tags say more about two pages being related than shared flags
+const (
+	relatedTagWeight  = 2
+	relatedFlagWeight = 1
+)
+
+// neighbours returns the pages before and after the i-th page, or nil at
+// the edges of the listing.
+func neighbours(pages []Page, i int) (*Page, *Page) {
+	var prev, next *Page
+	if i > 0 {
+		prev = &pages[i-1]
+	}
+	if i < len(pages)-1 {
+		next = &pages[i+1]
+	}
+	return prev, next
+}
+
+// relatedLimit returns how many related pages are computed for each page
+func relatedLimit(manifest ManifestFile) int {
+	if manifest.Related == nil {
+		return defaultRelatedLimit
+	}
+	if manifest.Related.Disabled {
+		return 0
+	}
+	if manifest.Related.Limit > 0 {
+		return manifest.Related.Limit
+	}
+	return defaultRelatedLimit
+}
+
+// relatedPages returns up to limit pages sharing tags or flags with the
+// page, the ones sharing the most first. Ties are broken by date, newest
+// first, and then by url.
+func relatedPages(page Page, pages []Page, limit int) []Page {
+	if limit <= 0 {
+		return nil
+	}
+
+	type scoredPage struct {
+		page  Page
+		score int
+	}
+
+	scored := make([]scoredPage, 0)
+	for _, candidate := range pages {
+		if candidate.Url == page.Url {
+			continue
+		}
+
+		score := relatedTagWeight*sharedCount(page.Tags, candidate.Tags) +
+			relatedFlagWeight*sharedCount(page.Flags, candidate.Flags)
+		if score > 0 {
+			scored = append(scored, scoredPage{page: candidate, score: score})
+		}
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		a, b := scored[i], scored[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		if !a.page.PublishedAt.Equal(b.page.PublishedAt.Time) {
+			return a.page.PublishedAt.After(b.page.PublishedAt.Time)
+		}
+		return a.page.Url < b.page.Url
+	})
+
+	related := make([]Page, 0, min(limit, len(scored)))
+	for _, s := range scored[:min(limit, len(scored))] {
+		related = append(related, s.page)
+	}
+	return related
+}
+
+// sharedCount returns how many values of a are also in b
+func sharedCount(a, b []string) int {
+	count := 0
+	for _, value := range a {
+		if contains(b, value) {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,80 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNeighbours(t *testing.T) {
+	pages := []Page{{Title: "a"}, {Title: "b"}, {Title: "c"}}
+
+	prev, next := neighbours(pages, 0)
+	assert.Nil(t, prev)
+	assert.Equal(t, "b", next.Title)
+
+	prev, next = neighbours(pages, 1)
+	assert.Equal(t, "a", prev.Title)
+	assert.Equal(t, "c", next.Title)
+
+	prev, next = neighbours(pages, 2)
+	assert.Equal(t, "b", prev.Title)
+	assert.Nil(t, next)
+}
+
+func TestRelatedPages(t *testing.T) {
+	page := Page{Url: "/blog/go.html", Tags: []string{"go", "testing"}, Flags: []string{"featured"}}
+	pages := []Page{
+		page,
+		{Title: "flag only", Url: "/blog/a.html", Flags: []string{"featured"}},
+		{Title: "one tag old", Url: "/blog/b.html", Tags: []string{"go"}, PublishedAt: mustDate("2023-01-01")},
+		{Title: "one tag new", Url: "/notes/c.html", Tags: []string{"testing"}, PublishedAt: mustDate("2024-01-01")},
+		{Title: "two tags", Url: "/blog/d.html", Tags: []string{"testing", "go"}},
+		{Title: "unrelated", Url: "/blog/e.html", Tags: []string{"rust"}},
+	}
+
+	assert.Equal(t, []string{"two tags", "one tag new", "one tag old", "flag only"}, titles(relatedPages(page, pages, 5)))
+	assert.Equal(t, []string{"two tags", "one tag new"}, titles(relatedPages(page, pages, 2)))
+	assert.Empty(t, relatedPages(page, pages, 0))
+}
+
+func TestRelatedLimit(t *testing.T) {
+	assert.Equal(t, defaultRelatedLimit, relatedLimit(ManifestFile{}))
+	assert.Equal(t, 3, relatedLimit(ManifestFile{Related: &RelatedConfig{Limit: 3}}))
+	assert.Equal(t, 0, relatedLimit(ManifestFile{Related: &RelatedConfig{Disabled: true}}))
+}
+
+func TestSchedulePageNavigation(t *testing.T) {
+	dir := t.TempDir()
+	writeContent(t, dir, map[string]string{
+		"blog/first.md":  "---\ntitle: First\npublished-at: 2024-01-01\ntags: [go]\n---\n",
+		"blog/second.md": "---\ntitle: Second\npublished-at: 2024-02-01\n---\n",
+		"blog/third.md":  "---\ntitle: Third\npublished-at: 2024-03-01\ntags: [go]\n---\n",
+	})
+
+	manifest := ManifestFile{
+		Sections: map[string]Section{
+			"blog": {ContentDir: "blog", SortBy: "published-at desc"},
+		},
+	}
+
+	tasks, _, err := scheduleTasks(manifest, dir, t.TempDir(), BuildOptions{})
+	assert.NoError(t, err)
+
+	pageTasks := map[string]*PageTask{}
+	for _, task := range tasks {
+		if task, ok := task.(*PageTask); ok {
+			pageTasks[task.Page.Title] = task
+		}
+	}
+
+	// Neighbours follow the sort order of the section
+	assert.Nil(t, pageTasks["Third"].Prev)
+	assert.Equal(t, "Second", pageTasks["Third"].Next.Title)
+	assert.Equal(t, "Third", pageTasks["Second"].Prev.Title)
+	assert.Equal(t, "First", pageTasks["Second"].Next.Title)
+	assert.Nil(t, pageTasks["First"].Next)
+
+	assert.Equal(t, []string{"Third"}, titles(pageTasks["First"].Related))
+	assert.Empty(t, pageTasks["Second"].Related)
+}
@@ -3,19 +3,18 @@ package generator
 import (
 	"fmt"
 	"html/template"
-	"io"
-	"net/http"
-	"os"
-	"path/filepath"
 )
 
-// TODO - For now we assume all data is HTTP API, with GET method, etc
+// ExternalDataTask maps a key of .ExternalData to a source of the manifest
 type ExternalDataTask struct {
-	Key string
-	Url string
+	Key    string
+	Source string
+	Api    ExternalApi
 }
 
 type PageData struct {
+	// Title is the title of the page in page templates, and the title of
+	// the site in layouts
 	Title        string
 	Tags         []string
 	HTML         template.HTML
@@ -23,6 +22,17 @@ type PageData struct {
 	Section      string
 	Sections     []string
 	ExternalData map[string]interface{}
+	// Page is the rendered page, with its summary, table of contents and
+	// reading time. Nil for pages other than markdown and html pages.
+	Page *Page
+	// Prev and Next are the pages around the page in its section, in the
+	// order of the section. Nil at the edges.
+	Prev    *Page
+	Next    *Page
+	Related []Page // Pages sharing tags or flags with the page
+	// Taxonomies is a shorthand for .Site.Taxonomies
+	Taxonomies map[string]Taxonomy
+	Site       *Site
 }
 
 type PageTask struct {
@@ -37,114 +47,104 @@ type PageTask struct {
 	Section           string
 	Sections          []string
 	ExternalDataTasks []ExternalDataTask
+	Page              Page
+	// The site and the pages around the page are covered by the hash of the
+	// site, encoding them with every page would grow with the square of the
+	// number of pages
+	Prev      *Page             `json:"-"`
+	Next      *Page             `json:"-"`
+	Related   []Page            `json:"-"`
+	Site      *Site             `json:"-"`
+	Templates *TemplateRegistry `json:"-"`
+	Fetcher   *DataFetcher      `json:"-"`
+
+	// externalData is fetched once and shared between Fingerprint and Execute
+	externalData    map[string]interface{}
+	externalDataErr error
 }
 
-func fetchData(url string) (interface{}, error) {
-	client := &http.Client{}
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		fmt.Println("Error creating request: ", err)
-		return nil, err
-	}
-
-	//req.Header.Set("Accept", "application/json")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		fmt.Println("Error executing request: ", err)
-		return nil, err
+func (t *PageTask) fetchExternalData() (map[string]interface{}, error) {
+	if t.externalData != nil || t.externalDataErr != nil {
+		return t.externalData, t.externalDataErr
 	}
 
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
+	externalData := make(map[string]interface{})
 
-		fmt.Println("Status: ", resp.StatusCode)
-		return nil, err
+	for _, task := range t.ExternalDataTasks {
+		data, err := t.Fetcher.Fetch(task.Source, task.Api)
+		if err != nil {
+			t.externalDataErr = fileError(t.InputFile, err)
+			return nil, t.externalDataErr
+		}
+		externalData[task.Key] = data
 	}
 
-	// Assume data is HTML for now
-	// TODO - Parse the data and return it as string
+	t.externalData = externalData
+	return externalData, nil
+}
 
-	data, err := io.ReadAll(resp.Body)
+// Fingerprint implements CacheableTask. The external data is fetched here
+// so that a change in the remote response also invalidates the page.
+func (t *PageTask) Fingerprint() (string, error) {
+	description := hashTask(t)
 
+	files, err := hashFiles(t.InputFile, t.Template, t.LayoutTemplate)
 	if err != nil {
-		fmt.Println("Error reading response body: ", err)
-		return nil, err
-	}
-
-	// TODO - Return the string, expose the safeHTML function in the templates
-	//  and make the client decide if the HTML is safe to render or not.
-	fmt.Println("Data: ", string(data))
-	return template.HTML(string(data)), nil
-}
-
-func getHtmlFromFile(filePath string) template.HTML {
-	if filePath == "" {
-		return template.HTML("")
+		return "", err
 	}
 
-	extension := filepath.Ext(filePath)
-
-	if extension == ".html" {
-		data, err := os.ReadFile(filePath)
-		if err != nil {
-			panic(fmt.Sprintf("Error reading file %s: %v", filePath, err))
-		}
-		return template.HTML(data)
+	partials, err := t.Templates.Hash()
+	if err != nil {
+		return "", err
 	}
 
-	if extension == ".md" {
-		// TODO - Use the markdown parser to convert the markdown to HTML
-		return generateMarkdownPage(filePath)
+	externalData, err := t.fetchExternalData()
+	if err != nil {
+		return "", err
 	}
 
-	panic(fmt.Sprintf("Unsupported file type %s for file %s", extension, filePath))
+	return hashStrings(description, t.Site.fingerprint(), files, partials, fmt.Sprintf("%#v", externalData)), nil
 }
-func (t PageTask) Execute() error {
-
-	html := getHtmlFromFile(t.InputFile)
-
-	externalData := make(map[string]interface{})
 
-	for _, task := range t.ExternalDataTasks {
-		fmt.Println("Fetching data for key: ", task.Key, " from URL: ", task.Url)
-		// Fetch Data from the external API
-		data, err := fetchData(task.Url)
-		// TODO - See how to handle errors
-		if err != nil {
-			externalData[task.Key] = nil
-		} else {
-			externalData[task.Key] = data
-		}
+func (t *PageTask) Execute() error {
+	externalData, err := t.fetchExternalData()
+	if err != nil {
+		return err
 	}
 
-	fmt.Println("External Data: ", externalData)
-
-	fmt.Println("Page Template: ", t.Template)
-	if t.Template != "" {
-		html = applyTemplate(t.Template, PageData{
-			// See how to get the title from the HTML
-			Title:        "",
-			Tags:         t.Tags,
-			Metadata:     t.Metadata,
-			HTML:         html,
-			ExternalData: externalData,
-		})
-	}
-	html = applyTemplate(t.LayoutTemplate, PageData{
-		Title:    t.Title,
-		Tags:     t.Tags,
-		Metadata: t.Metadata,
-		HTML:     html,
-		Section:  t.Section,
-		Sections: t.Sections,
+	html, err := t.Templates.Render(t.InputFile, t.LayoutTemplate, t.Template, PageData{
+		Title:        t.Page.Title,
+		Tags:         t.Tags,
+		Metadata:     t.Metadata,
+		HTML:         t.Page.HTML,
+		ExternalData: externalData,
+		Page:         &t.Page,
+		Prev:         t.Prev,
+		Next:         t.Next,
+		Related:      t.Related,
+		Taxonomies:   t.Site.taxonomies(),
+		Site:         t.Site,
+	}, PageData{
+		Title:      t.Title,
+		Tags:       t.Tags,
+		Metadata:   t.Metadata,
+		HTML:       t.Page.HTML,
+		Section:    t.Section,
+		Sections:   t.Sections,
+		Page:       &t.Page,
+		Prev:       t.Prev,
+		Next:       t.Next,
+		Related:    t.Related,
+		Taxonomies: t.Site.taxonomies(),
+		Site:       t.Site,
 	})
+	if err != nil {
+		return err
+	}
 
-	savePage(html, t.OutputFile)
-	return nil
+	return fileError(t.InputFile, savePage(html, t.OutputFile))
 }
 
-func (t PageTask) Name() string {
+func (t *PageTask) Name() string {
 	return t.OutputFile
 }
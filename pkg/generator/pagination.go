@@ -0,0 +1,94 @@
+package generator
+
+import (
+	"path"
+	"strconv"
+)
+
+// Paginator is exposed to section templates as .Paginator. Listings that
+// are not paginated have a single page with every page of the listing.
+type Paginator struct {
+	PageNumber int      // Current page, starting at 1
+	TotalPages int      // Number of html pages of the listing
+	PageSize   int      // Maximum number of pages listed in each html page
+	TotalItems int      // Number of pages of the whole listing
+	Pages      []Page   // Pages listed in the current html page
+	Urls       []string // Url of every html page of the listing, in order
+	PrevUrl    string   // Empty in the first page
+	NextUrl    string   // Empty in the last page
+}
+
+func (p *Paginator) HasPrev() bool {
+	return p.PrevUrl != ""
+}
+
+func (p *Paginator) HasNext() bool {
+	return p.NextUrl != ""
+}
+
+func (p *Paginator) FirstUrl() string {
+	return p.Urls[0]
+}
+
+func (p *Paginator) LastUrl() string {
+	return p.Urls[len(p.Urls)-1]
+}
+
+// listingPage is an html page of a paginated listing
+type listingPage struct {
+	Url        string
+	OutputFile string
+	Paginator  *Paginator
+}
+
+// paginate splits a listing of pages in groups of size pages. The first
+// group is written to firstUrl, the rest to <pagesDir>/page/<n>/. A size of
+// 0 or less disables pagination.
+func paginate(pages []Page, size int, firstUrl, firstOutputFile, pagesDir, outDir string) []listingPage {
+	if size <= 0 || len(pages) <= size {
+		size = max(len(pages), 1)
+	}
+
+	totalPages := (len(pages) + size - 1) / size
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	urls := make([]string, totalPages)
+	outputFiles := make([]string, totalPages)
+	urls[0] = firstUrl
+	outputFiles[0] = firstOutputFile
+	for i := 1; i < totalPages; i++ {
+		urls[i] = path.Join("/", pagesDir, "page", strconv.Itoa(i+1)) + "/"
+		outputFiles[i] = getFullPath(outDir, path.Join(urls[i], "index.html"))
+	}
+
+	listing := make([]listingPage, totalPages)
+	for i := 0; i < totalPages; i++ {
+		start := i * size
+		end := min(start+size, len(pages))
+
+		paginator := &Paginator{
+			PageNumber: i + 1,
+			TotalPages: totalPages,
+			PageSize:   size,
+			TotalItems: len(pages),
+			Pages:      pages[start:end],
+			Urls:       urls,
+		}
+		if i > 0 {
+			paginator.PrevUrl = urls[i-1]
+		}
+		if i < totalPages-1 {
+			paginator.NextUrl = urls[i+1]
+		}
+
+		listing[i] = listingPage{
+			Url:        urls[i],
+			OutputFile: outputFiles[i],
+			Paginator:  paginator,
+		}
+	}
+
+	return listing
+}
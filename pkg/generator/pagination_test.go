@@ -0,0 +1,60 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func makePages(n int) []Page {
+	pages := make([]Page, n)
+	for i := range pages {
+		pages[i] = Page{Title: fmt.Sprintf("Page %d", i+1)}
+	}
+	return pages
+}
+
+func TestPaginate_SplitsPages(t *testing.T) {
+	listing := paginate(makePages(5), 2, "/blog/", "out/blog/index.html", "blog", "out")
+
+	assert.Len(t, listing, 3)
+
+	assert.Equal(t, "/blog/", listing[0].Url)
+	assert.Equal(t, "out/blog/index.html", listing[0].OutputFile)
+	assert.Equal(t, "/blog/page/2/", listing[1].Url)
+	assert.Equal(t, filepath.Join("out", "blog", "page", "2", "index.html"), listing[1].OutputFile)
+
+	second := listing[1].Paginator
+	assert.Equal(t, 2, second.PageNumber)
+	assert.Equal(t, 3, second.TotalPages)
+	assert.Equal(t, 5, second.TotalItems)
+	assert.Equal(t, "/blog/", second.PrevUrl)
+	assert.Equal(t, "/blog/page/3/", second.NextUrl)
+	assert.Equal(t, []string{"Page 3", "Page 4"}, []string{second.Pages[0].Title, second.Pages[1].Title})
+
+	last := listing[2].Paginator
+	assert.True(t, last.HasPrev())
+	assert.False(t, last.HasNext())
+	assert.Len(t, last.Pages, 1)
+	assert.Equal(t, "/blog/", last.FirstUrl())
+	assert.Equal(t, "/blog/page/3/", last.LastUrl())
+}
+
+func TestPaginate_Disabled(t *testing.T) {
+	listing := paginate(makePages(5), 0, "/blog/", "out/blog/index.html", "blog", "out")
+
+	assert.Len(t, listing, 1)
+	assert.Len(t, listing[0].Paginator.Pages, 5)
+	assert.False(t, listing[0].Paginator.HasPrev())
+	assert.False(t, listing[0].Paginator.HasNext())
+}
+
+func TestPaginate_EmptyListing(t *testing.T) {
+	listing := paginate(nil, 10, "/blog/", "out/blog/index.html", "blog", "out")
+
+	assert.Len(t, listing, 1)
+	assert.Empty(t, listing[0].Paginator.Pages)
+	assert.Equal(t, 1, listing[0].Paginator.TotalPages)
+}
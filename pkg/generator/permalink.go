@@ -0,0 +1,156 @@
+package generator
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Permalink patterns of the sections that do not configure one
+const (
+	defaultPermalink = "/:section/:slug.html"
+	prettyPermalink  = "/:section/:slug/"
+)
+
+var permalinkToken = regexp.MustCompile(`:[a-z]+`)
+
+// Values of the permalink tokens for a page
+var permalinkTokens = map[string]func(section string, page Page) (string, error){
+	"section": func(section string, page Page) (string, error) {
+		return section, nil
+	},
+	"slug": func(section string, page Page) (string, error) {
+		return pageSlug(page), nil
+	},
+	"title": func(section string, page Page) (string, error) {
+		return slugify(page.Title), nil
+	},
+	"year":  publishedAtToken("2006"),
+	"month": publishedAtToken("01"),
+	"day":   publishedAtToken("02"),
+}
+
+func publishedAtToken(layout string) func(section string, page Page) (string, error) {
+	return func(section string, page Page) (string, error) {
+		if page.PublishedAt.IsZero() {
+			return "", fmt.Errorf("the page has no published-at date")
+		}
+		return page.PublishedAt.Format(layout), nil
+	}
+}
+
+// sectionPermalink returns the url pattern of the pages of a section
+func sectionPermalink(manifest ManifestFile, section Section) string {
+	if manifest.PrettyUrls {
+		return firstNonEmpty(section.Permalink, manifest.Permalink, prettyPermalink)
+	}
+	return firstNonEmpty(section.Permalink, manifest.Permalink, defaultPermalink)
+}
+
+// pageSlug returns the slug set in the page, or its file name without
+// extension. Page bundles use the name of their directory.
+func pageSlug(page Page) string {
+	if page.Slug != "" {
+		return page.Slug
+	}
+
+	dir, name := filepath.Split(page.MarkdownPath)
+	if isBundle(page) {
+		return filepath.Base(dir)
+	}
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// permalink expands the tokens of the pattern, e.g. /:section/:year/:slug/,
+// for the page. Urls ending with a slash are pretty urls, generated as the
+// index.html of that directory. Page bundles always get a directory, so
+// their resources can sit next to the page.
+func permalink(pattern, section string, page Page) (string, error) {
+	var err error
+	url := permalinkToken.ReplaceAllStringFunc(pattern, func(token string) string {
+		value, ok := permalinkTokens[token[1:]]
+		if !ok {
+			err = fmt.Errorf("unknown token %s, expected one of %s", token, strings.Join(permalinkTokenNames(), ", "))
+			return ""
+		}
+
+		expanded, tokenErr := value(section, page)
+		if tokenErr != nil {
+			err = fmt.Errorf("%s: %w", token, tokenErr)
+		}
+		return expanded
+	})
+	if err != nil {
+		return "", fmt.Errorf("permalink %q: %w", pattern, err)
+	}
+
+	pretty := strings.HasSuffix(url, "/")
+	url = path.Clean("/" + url)
+
+	if isBundle(page) && !pretty {
+		url = strings.TrimSuffix(url, path.Ext(url))
+		pretty = true
+	}
+	if pretty && url != "/" {
+		url += "/"
+	}
+
+	return url, nil
+}
+
+func permalinkTokenNames() []string {
+	names := make([]string, 0, len(permalinkTokens))
+	for name := range permalinkTokens {
+		names = append(names, ":"+name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// urlOutputFile returns the file generated for an url
+func urlOutputFile(outDir, url string) string {
+	if strings.HasSuffix(url, "/") {
+		url = path.Join(url, "index.html")
+	}
+	return getFullPath(outDir, url)
+}
+
+// checkCollisions fails when two tasks generate the same file, which would
+// silently overwrite one of the pages.
+func checkCollisions(tasks []Task) error {
+	sources := make(map[string]string)
+
+	for _, task := range tasks {
+		// Failed pages have no output
+		if _, ok := task.(*FailedTask); ok {
+			continue
+		}
+
+		output := filepath.Clean(task.Name())
+		source := taskSource(task)
+
+		if previous, ok := sources[output]; ok {
+			return fmt.Errorf("url collision: %s is generated by both %s and %s, set a different slug or permalink for one of them", output, previous, source)
+		}
+		sources[output] = source
+	}
+
+	return nil
+}
+
+// taskSource describes where the output of a task comes from
+func taskSource(task Task) string {
+	switch t := task.(type) {
+	case *PageTask:
+		return t.InputFile
+	case *CopyTask:
+		return t.FromPath
+	case *SectionTask:
+		return fmt.Sprintf("the listing of section %s", t.Section)
+	default:
+		return strings.TrimPrefix(fmt.Sprintf("%T", task), "*generator.")
+	}
+}
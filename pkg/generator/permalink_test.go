@@ -0,0 +1,104 @@
+package generator
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPermalink(t *testing.T) {
+	page := Page{MarkdownPath: "posts/Hello World.md", Title: "Hello, World", PublishedAt: mustDate("2024-03-05")}
+	bundle := Page{MarkdownPath: "posts/trip/index.md"}
+
+	tests := []struct {
+		pattern  string
+		page     Page
+		expected string
+	}{
+		{defaultPermalink, page, "/blog/Hello World.html"},
+		{prettyPermalink, page, "/blog/Hello World/"},
+		{"/:section/:year/:month/:day/:title/", page, "/blog/2024/03/05/hello-world/"},
+		{"/articles/:slug.html", Page{MarkdownPath: "a.md", Slug: "custom"}, "/articles/custom.html"},
+		{defaultPermalink, bundle, "/blog/trip/"},
+		{prettyPermalink, bundle, "/blog/trip/"},
+		{"/", page, "/"},
+	}
+
+	for _, test := range tests {
+		url, err := permalink(test.pattern, "blog", test.page)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, url, test.pattern)
+	}
+
+	_, err := permalink("/:section/:author/", "blog", page)
+	assert.ErrorContains(t, err, "unknown token :author")
+
+	_, err = permalink("/:year/:slug/", "blog", bundle)
+	assert.ErrorContains(t, err, "no published-at date")
+}
+
+func TestSectionPermalink(t *testing.T) {
+	assert.Equal(t, defaultPermalink, sectionPermalink(ManifestFile{}, Section{}))
+	assert.Equal(t, prettyPermalink, sectionPermalink(ManifestFile{PrettyUrls: true}, Section{}))
+	assert.Equal(t, "/:slug/", sectionPermalink(ManifestFile{Permalink: "/:slug/"}, Section{}))
+	assert.Equal(t, "/:year/:slug/", sectionPermalink(ManifestFile{Permalink: "/:slug/"}, Section{Permalink: "/:year/:slug/"}))
+}
+
+func TestUrlOutputFile(t *testing.T) {
+	assert.Equal(t, filepath.Join("out", "blog", "post", "index.html"), urlOutputFile("out", "/blog/post/"))
+	assert.Equal(t, filepath.Join("out", "blog", "post.html"), urlOutputFile("out", "/blog/post.html"))
+}
+
+func TestSchedulePermalinks(t *testing.T) {
+	dir := t.TempDir()
+	writeContent(t, dir, map[string]string{
+		"blog/2023/intro.md": "---\ntitle: Old intro\npublished-at: 2023-01-01\n---\n",
+		"blog/2024/intro.md": "---\ntitle: New intro\npublished-at: 2024-01-01\nslug: hello\n---\n",
+		"layout.html":        "{{ .HTML }}",
+		"page.html":          "{{ .Page.Url }}",
+	})
+
+	manifest := ManifestFile{
+		DefaultLayoutTemplate: "layout.html",
+		DefaultPageTemplate:   "page.html",
+		PrettyUrls:            true,
+		Sections: map[string]Section{
+			"blog": {ContentDir: "blog", Permalink: "/:section/:year/:slug/"},
+		},
+	}
+
+	outDir := t.TempDir()
+	tasks, _, err := scheduleTasks(manifest, dir, outDir, BuildOptions{})
+	assert.NoError(t, err)
+
+	outputs := make([]string, 0)
+	for _, task := range tasks {
+		outputs = append(outputs, task.Name())
+	}
+	assert.Contains(t, outputs, filepath.Join(outDir, "blog", "2023", "intro", "index.html"))
+	assert.Contains(t, outputs, filepath.Join(outDir, "blog", "2024", "hello", "index.html"))
+}
+
+func TestScheduleDetectsCollisions(t *testing.T) {
+	dir := t.TempDir()
+	writeContent(t, dir, map[string]string{
+		"blog/2023/intro.md": "# Old intro\n",
+		"blog/2024/intro.md": "# New intro\n",
+		"layout.html":        "{{ .HTML }}",
+		"page.html":          "{{ .HTML }}",
+	})
+
+	manifest := ManifestFile{
+		DefaultLayoutTemplate: "layout.html",
+		DefaultPageTemplate:   "page.html",
+		Sections: map[string]Section{
+			"blog": {ContentDir: "blog"},
+		},
+	}
+
+	_, _, err := scheduleTasks(manifest, dir, t.TempDir(), BuildOptions{})
+	assert.ErrorContains(t, err, "url collision")
+	assert.ErrorContains(t, err, filepath.Join("blog", "2023", "intro.md"))
+	assert.ErrorContains(t, err, filepath.Join("blog", "2024", "intro.md"))
+}
@@ -0,0 +1,34 @@
+package generator
+
+import "time"
+
+// Pages with this flag are handled as drafts, like pages with draft: true
+const draftFlag = "draft"
+
+func isDraft(page Page) bool {
+	return page.Draft || contains(page.Flags, draftFlag)
+}
+
+func isFuture(page Page, now time.Time) bool {
+	return page.PublishedAt.After(now)
+}
+
+func isExpired(page Page, now time.Time) bool {
+	return !page.ExpiresAt.IsZero() && !page.ExpiresAt.After(now)
+}
+
+// isPublished reports whether the page is part of the build. Drafts, pages
+// published in the future and expired pages are left out unless the build
+// options ask for them.
+func isPublished(page Page, opts BuildOptions, now time.Time) bool {
+	if isDraft(page) && !opts.Drafts {
+		return false
+	}
+	if isFuture(page, now) && !opts.Future {
+		return false
+	}
+	if isExpired(page, now) && !opts.Expired {
+		return false
+	}
+	return true
+}
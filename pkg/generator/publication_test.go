@@ -0,0 +1,73 @@
+package generator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsPublished(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	published := Page{PublishedAt: mustDate("2024-05-01")}
+	draft := Page{Draft: true}
+	draftFlagged := Page{Flags: []string{"draft"}}
+	future := Page{PublishedAt: mustDate("2024-06-02")}
+	expired := Page{PublishedAt: mustDate("2024-01-01"), ExpiresAt: mustDate("2024-06-01")}
+	expiring := Page{ExpiresAt: mustDate("2024-07-01")}
+
+	assert.True(t, isPublished(published, BuildOptions{}, now))
+	assert.True(t, isPublished(Page{}, BuildOptions{}, now))
+	assert.True(t, isPublished(expiring, BuildOptions{}, now))
+
+	assert.False(t, isPublished(draft, BuildOptions{}, now))
+	assert.False(t, isPublished(draftFlagged, BuildOptions{}, now))
+	assert.False(t, isPublished(future, BuildOptions{}, now))
+	assert.False(t, isPublished(expired, BuildOptions{}, now))
+
+	assert.True(t, isPublished(draft, BuildOptions{Drafts: true}, now))
+	assert.True(t, isPublished(draftFlagged, BuildOptions{Drafts: true}, now))
+	assert.True(t, isPublished(future, BuildOptions{Future: true}, now))
+	assert.True(t, isPublished(expired, BuildOptions{Expired: true}, now))
+
+	// Every option is required for pages matching several conditions
+	futureDraft := Page{Draft: true, PublishedAt: mustDate("2024-06-02")}
+	assert.False(t, isPublished(futureDraft, BuildOptions{Drafts: true}, now))
+	assert.True(t, isPublished(futureDraft, BuildOptions{Drafts: true, Future: true}, now))
+}
+
+func TestScheduleTasksSkipsUnpublishedPages(t *testing.T) {
+	dir := t.TempDir()
+	writeContent(t, dir, map[string]string{
+		"posts/live.md":    "---\ntitle: Live\n---\n# Live",
+		"posts/draft.md":   "---\ntitle: Draft\ndraft: true\n---\n# Draft",
+		"posts/future.md":  "---\ntitle: Future\npublished-at: 2999-01-01\n---\n# Future",
+		"posts/expired.md": "---\ntitle: Expired\nexpires-at: 2000-01-01\n---\n# Expired",
+	})
+
+	manifest := ManifestFile{
+		Title:               "Site",
+		DefaultPageTemplate: "page.html",
+		Sections: map[string]Section{
+			"posts": {ContentDir: "posts"},
+		},
+	}
+
+	pageOutputs := func(opts BuildOptions) []string {
+		tasks, _, err := scheduleTasks(manifest, dir, t.TempDir(), opts)
+		assert.NoError(t, err)
+
+		urls := []string{}
+		for _, task := range tasks {
+			if page, ok := task.(*PageTask); ok {
+				urls = append(urls, page.Url)
+			}
+		}
+		return urls
+	}
+
+	assert.ElementsMatch(t, []string{"/posts/live.html"}, pageOutputs(BuildOptions{}))
+	assert.ElementsMatch(t, []string{"/posts/live.html", "/posts/draft.html"}, pageOutputs(BuildOptions{Drafts: true}))
+	assert.ElementsMatch(t, []string{"/posts/live.html", "/posts/future.html", "/posts/expired.html"}, pageOutputs(BuildOptions{Future: true, Expired: true}))
+}
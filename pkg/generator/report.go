@@ -0,0 +1,69 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BuildReport summarizes the outcome of a build. Failures are collected so
+// that every broken file can be reported at once.
+type BuildReport struct {
+	Total     int
+	Completed int
+	Skipped   int
+	Failures  []FileProgress
+}
+
+func NewBuildReport(total int) *BuildReport {
+	return &BuildReport{Total: total}
+}
+
+// Add records a progress update. Only final statuses are counted.
+func (r *BuildReport) Add(progress FileProgress) {
+	switch progress.Status {
+	case Completed:
+		r.Completed++
+	case Skipped:
+		r.Skipped++
+	case Failed:
+		r.Failures = append(r.Failures, progress)
+	}
+}
+
+func (r *BuildReport) Done() int {
+	return r.Completed + r.Skipped + len(r.Failures)
+}
+
+func (r *BuildReport) HasFailures() bool {
+	return len(r.Failures) > 0
+}
+
+// Err returns an error when at least one file failed to generate
+func (r *BuildReport) Err() error {
+	if !r.HasFailures() {
+		return nil
+	}
+	return fmt.Errorf("%d of %d files failed to generate", len(r.Failures), r.Total)
+}
+
+func (r *BuildReport) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Generated %d / %d files", r.Completed+r.Skipped, r.Total)
+	if r.Skipped > 0 {
+		fmt.Fprintf(&b, " (%d unchanged)", r.Skipped)
+	}
+	b.WriteString("\n")
+
+	if r.HasFailures() {
+		fmt.Fprintf(&b, "%d files failed:\n", len(r.Failures))
+		for _, failure := range r.Failures {
+			fmt.Fprintf(&b, "  ✗ %s\n", failure.Filename)
+			if failure.Err != nil {
+				fmt.Fprintf(&b, "      %s\n", failure.Err)
+			}
+		}
+	}
+
+	return b.String()
+}
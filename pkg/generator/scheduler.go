@@ -3,7 +3,10 @@ package generator
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"time"
 )
 
 type Task interface {
@@ -20,8 +23,29 @@ func getFullPath(baseDir, relativePath string) string {
 	return filepath.Join(baseDir, relativePath)
 }
 
-func scheduleTasks(manifest ManifestFile, baseDir, outDir string) []Task {
+// firstNonEmpty returns the first value that is set, used to resolve the
+// templates overridden by sections and pages
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// scheduleTasks returns the tasks generating the site, and the site they
+// render
+func scheduleTasks(manifest ManifestFile, baseDir, outDir string, opts BuildOptions) ([]Task, *Site, error) {
 	tasks := make([]Task, 0)
+	now := time.Now()
+
+	fetcher := newDataFetcher(externalDataCacheDir(baseDir))
+
+	templates, err := newTemplateRegistry(partialsDir(manifest, baseDir), templateFuncs(manifest.BaseUrl, now))
+	if err != nil {
+		return nil, nil, err
+	}
 
 	// Copy static files
 	for _, asset := range manifest.StaticAssets {
@@ -37,9 +61,100 @@ func scheduleTasks(manifest ManifestFile, baseDir, outDir string) []Task {
 	for section, _ := range manifest.Sections {
 		sections = append(sections, section)
 	}
+	// Map iteration order is random, keep the tasks stable between builds
+	sort.Strings(sections)
+
+	sitemap := make([]SitemapEntry, 0)
+
+	// Load the pages of every section first, taxonomies need all of them
+	sectionPagesByName := make(map[string][]Page)
+	allPages := make([]Page, 0)
+
+	for _, sectionName := range sections {
+		section := manifest.Sections[sectionName]
+
+		declaredPages, err := sectionPages(baseDir, section)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		permalinkPattern := sectionPermalink(manifest, section)
+
+		pages := make([]Page, 0, len(declaredPages))
+		for _, page := range declaredPages {
+			inputFile := getFullPath(baseDir, page.MarkdownPath)
+
+			// Broken pages are left out of the site and reported as failed
+			page, body, err := readPage(baseDir, page)
+			if err != nil {
+				tasks = append(tasks, &FailedTask{InputFile: inputFile, Err: err})
+				continue
+			}
+			if !isPublished(page, opts, now) {
+				continue
+			}
+			page.Section = sectionName
+
+			// The url is needed to resolve the relative links of the page
+			page.Url, err = permalink(permalinkPattern, sectionName, page)
+			if err != nil {
+				tasks = append(tasks, &FailedTask{InputFile: inputFile, Err: fileError(inputFile, err)})
+				continue
+			}
+
+			page, err = renderPage(baseDir, page, body)
+			if err != nil {
+				tasks = append(tasks, &FailedTask{InputFile: inputFile, Err: err})
+				continue
+			}
+
+			page.Resources, err = bundleResources(baseDir, outDir, page)
+			if err != nil {
+				tasks = append(tasks, &FailedTask{InputFile: inputFile, Err: err})
+				continue
+			}
+			pages = append(pages, page)
+
+			if isIndexable(page) {
+				sitemap = append(sitemap, SitemapEntry{Url: page.Url, LastMod: pageLastMod(page)})
+			}
+		}
+
+		if section.SortBy != "" {
+			pages, err = sortPages(section.SortBy, pages)
+			if err != nil {
+				return nil, nil, fmt.Errorf("section %s: %w", sectionName, err)
+			}
+		}
+
+		sectionPagesByName[sectionName] = pages
+		allPages = append(allPages, pages...)
+	}
+
+	taxonomyConfigs := siteTaxonomies(manifest)
+	taxonomies := make(map[string]Taxonomy)
+	for name, config := range taxonomyConfigs {
+		urlDir := ""
+		if config.SiteWide && termTemplate(manifest, config) != "" {
+			urlDir = name
+		}
+		taxonomy := collectTerms(name, allPages, urlDir)
+		if config.SiteWide && config.IndexTemplate != "" {
+			taxonomy.Url = path.Join("/", name) + "/"
+		}
+		taxonomies[name] = taxonomy
+	}
+
+	site := newSite(manifest, sections, sectionPagesByName, taxonomies, now)
+	site.Data, err = loadDataDir(dataDir(manifest, baseDir))
+	if err != nil {
+		return nil, nil, err
+	}
+	site.hash = hashSite(site)
 
 	if manifest.HomeTemplate != "" {
 		homePath := filepath.Join(outDir, "index.html")
+		sitemap = append(sitemap, SitemapEntry{Url: "/"})
 
 		tasks = append(tasks, &HomeTask{
 			Title:          manifest.Title,
@@ -48,90 +163,156 @@ func scheduleTasks(manifest ManifestFile, baseDir, outDir string) []Task {
 			Template:       getFullPath(baseDir, manifest.HomeTemplate),
 			LayoutTemplate: getFullPath(baseDir, manifest.DefaultLayoutTemplate),
 			Metadata:       manifest.Metadata,
+			Site:           site,
+			Templates:      templates,
 		})
 	}
 
-	for sectionName, section := range manifest.Sections {
-		tags := make(map[string][]Page)
+	for _, sectionName := range sections {
+		section := manifest.Sections[sectionName]
+		pages := sectionPagesByName[sectionName]
+
+		if config := sectionFeedConfig(manifest, section); config != nil {
+			title := fmt.Sprintf("%s - %s", manifest.Title, sectionName)
+			feeds, err := feedTasks(manifest, config, outDir, sectionName, title, pages, now)
+			if err != nil {
+				return nil, nil, err
+			}
+			tasks = append(tasks, feeds...)
+		}
 
 		sectionBasePath := getFullPath(outDir, sectionName)
 
+		sectionTemplate := firstNonEmpty(section.Template, manifest.DefaultSectionTemplate)
+
 		// Do not generate the section page if there is no template configured
-		if manifest.DefaultSectionTemplate != "" {
+		if sectionTemplate != "" {
 			os.MkdirAll(sectionBasePath, 0755)
 
 			outFile := getFullPath(sectionBasePath, "index.html")
+			listing := paginate(pages, section.Paginate, path.Join("/", sectionName)+"/", outFile, sectionName, outDir)
 
-			tasks = append(tasks, &SectionTask{
-				Title:          manifest.Title,
-				Section:        sectionName,
-				Sections:       sections,
-				OutputFile:     outFile,
-				Template:       getFullPath(baseDir, manifest.DefaultSectionTemplate),
-				LayoutTemplate: getFullPath(baseDir, manifest.DefaultLayoutTemplate),
-				Pages:          section.Pages,
-				Metadata:       merge(manifest.Metadata, section.Metadata),
-			})
-		}
-
-		for _, page := range section.Pages {
-			outputFilename := convertExtension(page.MarkdownPath, ".html")
-			outPath := getFullPath(sectionBasePath, outputFilename)
+			for _, listingPage := range listing {
+				sitemap = append(sitemap, SitemapEntry{
+					Url:     listingPage.Url,
+					LastMod: latestLastMod(listingPage.Paginator.Pages),
+				})
 
-			externalDataTasks := []ExternalDataTask{}
+				tasks = append(tasks, &SectionTask{
+					Title:          manifest.Title,
+					Section:        sectionName,
+					Sections:       sections,
+					OutputFile:     listingPage.OutputFile,
+					Template:       getFullPath(baseDir, sectionTemplate),
+					LayoutTemplate: getFullPath(baseDir, manifest.DefaultLayoutTemplate),
+					Pages:          pages,
+					Paginator:      listingPage.Paginator,
+					Metadata:       merge(manifest.Metadata, section.Metadata),
+					Site:           site,
+					Templates:      templates,
+				})
+			}
+		}
 
-			for key, value := range page.ExternalData {
+		for i, page := range pages {
+			prev, next := neighbours(pages, i)
 
-				fmt.Println("\t\tKey: ", key, " Source: ", value.Source, " Url: ", manifest.ExternalData[value.Source].Url)
+			outPath := urlOutputFile(outDir, page.Url)
 
-				externalDataTasks = append(externalDataTasks, ExternalDataTask{
-					Key: key,
-					Url: manifest.ExternalData[value.Source].Url,
-				})
+			externalDataTasks, err := pageExternalData(manifest, baseDir, page)
+			if err != nil {
+				inputFile := getFullPath(baseDir, page.MarkdownPath)
+				tasks = append(tasks, &FailedTask{InputFile: inputFile, Err: fileError(inputFile, err)})
+				continue
 			}
 
-			tasks = append(tasks, PageTask{
+			tasks = append(tasks, &PageTask{
 				Title:             manifest.Title,
 				InputFile:         getFullPath(baseDir, page.MarkdownPath),
 				OutputFile:        outPath,
-				Url:               filepath.Join("/", sectionName, outputFilename),
-				Template:          getFullPath(baseDir, manifest.DefaultPageTemplate),
-				LayoutTemplate:    getFullPath(baseDir, manifest.DefaultLayoutTemplate),
+				Url:               page.Url,
+				Template:          getFullPath(baseDir, firstNonEmpty(page.Template, section.PageTemplate, manifest.DefaultPageTemplate)),
+				LayoutTemplate:    getFullPath(baseDir, firstNonEmpty(page.Layout, manifest.DefaultLayoutTemplate)),
 				Metadata:          merge(manifest.Metadata, page.Metadata),
 				Tags:              page.Tags,
 				Section:           sectionName,
 				Sections:          sections,
 				ExternalDataTasks: externalDataTasks,
+				Page:              page,
+				Prev:              prev,
+				Next:              next,
+				Related:           relatedPages(page, site.Pages, relatedLimit(manifest)),
+				Site:              site,
+				Templates:         templates,
+				Fetcher:           fetcher,
 			})
+			tasks = append(tasks, resourceTasks(baseDir, outPath, page)...)
+		}
+
+		for _, name := range taxonomyNames(taxonomyConfigs) {
+			config := taxonomyConfigs[name]
+			dir := path.Join(sectionName, name)
 
-			for _, tag := range page.Tags {
-				if _, ok := tags[tag]; !ok {
-					tags[tag] = make([]Page, 0)
-				}
-				tags[tag] = append(tags[tag], page)
+			urlDir := ""
+			if termTemplate(manifest, config) != "" {
+				urlDir = dir
 			}
-		}
 
-		tagsBasePath := filepath.Join(sectionBasePath, "tags")
-
-		for tag, pages := range tags {
-			tagOutputFile := filepath.Join(tagsBasePath, slugify(tag)+".html")
-			os.MkdirAll(tagsBasePath, 0755)
-
-			// TODO - Create specific task for tags
-			tasks = append(tasks, &SectionTask{
-				Title:          manifest.Title,
-				OutputFile:     tagOutputFile,
-				Template:       getFullPath(baseDir, manifest.DefaultSectionTemplate),
-				LayoutTemplate: getFullPath(baseDir, manifest.DefaultLayoutTemplate),
-				Pages:          pages,
-				Section:        sectionName,
-				Sections:       sections,
-				Metadata:       merge(manifest.Metadata, section.Metadata),
+			termPages, termSitemap := termTasks(manifest, templates, baseDir, outDir, sections, site, termListing{
+				Taxonomy: collectTerms(name, pages, urlDir),
+				Config:   config,
+				Section:  sectionName,
+				Dir:      dir,
+				Paginate: section.Paginate,
+				Metadata: merge(manifest.Metadata, section.Metadata),
 			})
+			tasks = append(tasks, termPages...)
+			sitemap = append(sitemap, termSitemap...)
+		}
+	}
+
+	for _, name := range taxonomyNames(taxonomyConfigs) {
+		config := taxonomyConfigs[name]
+		if !config.SiteWide {
+			continue
+		}
+
+		termPages, termSitemap := termTasks(manifest, templates, baseDir, outDir, sections, site, termListing{
+			Taxonomy: taxonomies[name],
+			Config:   config,
+			Dir:      name,
+			Paginate: config.Paginate,
+			Metadata: manifest.Metadata,
+		})
+		tasks = append(tasks, termPages...)
+		sitemap = append(sitemap, termSitemap...)
+	}
 
+	if manifest.Feeds != nil && manifest.Feeds.SiteWide && !manifest.Feeds.Disabled {
+		sort.SliceStable(allPages, func(i, j int) bool {
+			return allPages[i].Url < allPages[j].Url
+		})
+		feeds, err := feedTasks(manifest, manifest.Feeds, outDir, "/", manifest.Title, allPages, now)
+		if err != nil {
+			return nil, nil, err
 		}
+		tasks = append(tasks, feeds...)
+	}
+
+	if sitemapEnabled(manifest) {
+		tasks = append(tasks, newSitemapTask(manifest.BaseUrl, filepath.Join(outDir, "sitemap.xml"), sitemap))
+	}
+
+	if robotsEnabled(manifest) {
+		tasks = append(tasks, &RobotsTask{
+			OutputFile: filepath.Join(outDir, "robots.txt"),
+			Content:    robotsContent(manifest),
+		})
+	}
+
+	if err := checkCollisions(tasks); err != nil {
+		return nil, nil, err
 	}
 
-	return tasks
+	return tasks, site, nil
 }
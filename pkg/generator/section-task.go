@@ -1,11 +1,5 @@
 package generator
 
-import (
-	"bytes"
-	"html/template"
-	"path/filepath"
-)
-
 type SectionTask struct {
 	Title          string
 	Section        string
@@ -14,37 +8,59 @@ type SectionTask struct {
 	Template       string
 	LayoutTemplate string
 	Pages          []Page
+	Paginator      *Paginator
 	Metadata       map[string]string
+	Site           *Site             `json:"-"`
+	Templates      *TemplateRegistry `json:"-"`
 }
 
+// SectionData is the data passed to section templates. When the section is
+// paginated, Pages only holds the pages listed in the current html page.
 type SectionData struct {
-	Section  string
-	Metadata map[string]string
-	Pages    []Page
+	Section   string
+	Metadata  map[string]string
+	Pages     []Page
+	Paginator *Paginator
+	// Taxonomies is a shorthand for .Site.Taxonomies
+	Taxonomies map[string]Taxonomy
+	Site       *Site
 }
 
 func (t SectionTask) Execute() error {
-	html := bytes.NewBufferString("")
-	funcMap := template.FuncMap{
-		"where": wherePages,
+	paginator := t.Paginator
+	if paginator == nil {
+		paginator = paginate(t.Pages, 0, "", t.OutputFile, "", "")[0].Paginator
 	}
-	tmpl := template.Must(template.New(filepath.Base(t.Template)).Funcs(funcMap).ParseFiles(t.Template))
 
-	tmpl.Execute(html, SectionData{
-		Section: t.Section,
-		Pages:   t.Pages,
-	})
+	data := SectionData{
+		Section:    t.Section,
+		Pages:      paginator.Pages,
+		Paginator:  paginator,
+		Taxonomies: t.Site.taxonomies(),
+		Site:       t.Site,
+	}
 
-	html2 := applyTemplate(t.LayoutTemplate, PageData{
-		Title:    t.Title,
-		HTML:     template.HTML(html.String()),
-		Section:  t.Section,
-		Sections: t.Sections,
-		Metadata: t.Metadata,
+	return t.Templates.savePageTo(t.OutputFile, t.LayoutTemplate, t.Template, data, PageData{
+		Title:      t.Title,
+		Section:    t.Section,
+		Sections:   t.Sections,
+		Metadata:   t.Metadata,
+		Taxonomies: t.Site.taxonomies(),
+		Site:       t.Site,
 	})
+}
 
-	savePage(html2, t.OutputFile)
-	return nil
+// Fingerprint implements CacheableTask
+func (t SectionTask) Fingerprint() (string, error) {
+	files, err := hashFiles(t.Template, t.LayoutTemplate)
+	if err != nil {
+		return "", err
+	}
+	partials, err := t.Templates.Hash()
+	if err != nil {
+		return "", err
+	}
+	return hashStrings(hashTask(t), t.Site.fingerprint(), hashPages(t.Pages), files, partials), nil
 }
 
 func (t SectionTask) Name() string {
@@ -0,0 +1,107 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"time"
+)
+
+// Site is exposed to every template as .Site, e.g. to list the recent posts
+// of every section in the home page:
+//
+//	{{ range .Site.Pages | sortBy "published-at desc" | first 5 }}
+type Site struct {
+	Title      string
+	BaseUrl    string
+	Metadata   map[string]string
+	Sections   []SiteSection // Sorted by name
+	Pages      []Page        // Pages of every section
+	Taxonomies map[string]Taxonomy
+	// Data holds the files of the data directory, e.g. .Site.Data.team
+	Data map[string]interface{}
+	// BuildTime is left out of the task fingerprints, so it does not
+	// invalidate the build cache on every build
+	BuildTime time.Time `json:"-"`
+
+	// hash is computed once per build and added to the fingerprint of every
+	// task rendering the site, instead of encoding the site in each of them
+	hash string
+}
+
+// SiteSection is a section of the site with its pages
+type SiteSection struct {
+	Name     string
+	Url      string
+	Metadata map[string]string
+	Pages    []Page
+}
+
+// Section returns the section with the given name, or an empty section
+func (s *Site) Section(name string) SiteSection {
+	for _, section := range s.Sections {
+		if section.Name == name {
+			return section
+		}
+	}
+	return SiteSection{}
+}
+
+// taxonomies returns the taxonomies of the site, or nil without site
+func (s *Site) taxonomies() map[string]Taxonomy {
+	if s == nil {
+		return nil
+	}
+	return s.Taxonomies
+}
+
+// pages returns the pages of the site, or nil without site
+func (s *Site) pages() []Page {
+	if s == nil {
+		return nil
+	}
+	return s.Pages
+}
+
+// fingerprint returns the hash of the site, or an empty string without site
+func (s *Site) fingerprint() string {
+	if s == nil {
+		return ""
+	}
+	return s.hash
+}
+
+// hashSite hashes the content of the site, like hashTask does for tasks
+func hashSite(site *Site) string {
+	data, err := json.Marshal(site)
+	if err != nil {
+		// Never report a stale task as fresh
+		return hashStrings(err.Error(), fmt.Sprint(time.Now().UnixNano()))
+	}
+	return hashStrings(string(data))
+}
+
+// newSite builds the site from the published pages of every section
+func newSite(manifest ManifestFile, sections []string, pages map[string][]Page, taxonomies map[string]Taxonomy, buildTime time.Time) *Site {
+	site := &Site{
+		Title:      manifest.Title,
+		BaseUrl:    manifest.BaseUrl,
+		Metadata:   manifest.Metadata,
+		Sections:   make([]SiteSection, 0, len(sections)),
+		Pages:      make([]Page, 0),
+		Taxonomies: taxonomies,
+		BuildTime:  buildTime,
+	}
+
+	for _, name := range sections {
+		site.Sections = append(site.Sections, SiteSection{
+			Name:     name,
+			Url:      path.Join("/", name) + "/",
+			Metadata: merge(manifest.Metadata, manifest.Sections[name].Metadata),
+			Pages:    pages[name],
+		})
+		site.Pages = append(site.Pages, pages[name]...)
+	}
+
+	return site
+}
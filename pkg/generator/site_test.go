@@ -0,0 +1,81 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSiteInTemplates(t *testing.T) {
+	dir := t.TempDir()
+	writeContent(t, dir, map[string]string{
+		"blog/old.md":  "---\ntitle: Old\npublished-at: 2023-01-01\ntags: [go]\n---\n# Old",
+		"blog/new.md":  "---\ntitle: New\npublished-at: 2024-06-01\n---\n# New",
+		"notes/mid.md": "---\ntitle: Mid\npublished-at: 2024-01-01\n---\n# Mid",
+		"layout.html":  "{{ .Site.Title }}|{{ range .Site.Sections }}{{ .Url }};{{ end }}|{{ .HTML }}",
+		"home.html":    "{{ range .Site.Pages | sortBy \"published-at desc\" | first 2 }}{{ .Title }};{{ end }}{{ len (.Site.Section \"blog\").Pages }} {{ .Site.BaseUrl }} {{ ((index .Site.Taxonomies \"tags\").Term \"go\").Count }}",
+	})
+
+	manifest := ManifestFile{
+		Title:                 "Site",
+		BaseUrl:               "https://example.com",
+		DefaultLayoutTemplate: "layout.html",
+		HomeTemplate:          "home.html",
+		Sitemap:               &SitemapConfig{Disabled: true},
+		Robots:                &RobotsConfig{Disabled: true},
+		Sections: map[string]Section{
+			"blog":  {ContentDir: "blog"},
+			"notes": {ContentDir: "notes"},
+		},
+	}
+
+	outDir := t.TempDir()
+	tasks, _, err := scheduleTasks(manifest, dir, outDir, BuildOptions{})
+	assert.NoError(t, err)
+
+	var home *HomeTask
+	for _, task := range tasks {
+		if task, ok := task.(*HomeTask); ok {
+			home = task
+		}
+	}
+	if !assert.NotNil(t, home) {
+		return
+	}
+
+	assert.Equal(t, []string{"blog", "notes"}, []string{home.Site.Sections[0].Name, home.Site.Sections[1].Name})
+	assert.Len(t, home.Site.Pages, 3)
+	assert.False(t, home.Site.BuildTime.IsZero())
+
+	assert.NoError(t, home.Execute())
+	content, err := os.ReadFile(filepath.Join(outDir, "index.html"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Site|/blog/;/notes/;|New;Mid;2 https://example.com 1", string(content))
+}
+
+func TestSiteBuildTimeKeepsFingerprints(t *testing.T) {
+	manifestPath, _ := prepareSimpleBlog(t)
+	manifest, err := getManifest([]string{manifestPath})
+	assert.NoError(t, err)
+	dir := filepath.Dir(manifestPath)
+	outDir := t.TempDir()
+
+	fingerprints := func() []string {
+		tasks, _, err := scheduleTasks(manifest, dir, outDir, BuildOptions{})
+		assert.NoError(t, err)
+
+		result := []string{}
+		for _, task := range tasks {
+			if cacheable, ok := task.(CacheableTask); ok {
+				fingerprint, err := cacheable.Fingerprint()
+				assert.NoError(t, err)
+				result = append(result, fingerprint)
+			}
+		}
+		return result
+	}
+
+	assert.Equal(t, fingerprints(), fingerprints())
+}
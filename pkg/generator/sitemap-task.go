@@ -0,0 +1,173 @@
+package generator
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Pages with this flag are left out of the sitemap
+const noIndexFlag = "noindex"
+
+// SitemapEntry is an url listed in the sitemap
+type SitemapEntry struct {
+	Url     string
+	LastMod time.Time
+}
+
+// SitemapTask writes sitemap.xml with every url generated for the site
+type SitemapTask struct {
+	BaseUrl    string
+	OutputFile string
+	Entries    []SitemapEntry
+}
+
+type sitemapUrlSet struct {
+	XMLName xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	Urls    []sitemapUrl `xml:"url"`
+}
+
+type sitemapUrl struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// newSitemapTask sorts the entries so that the task, and its fingerprint,
+// do not depend on the order sections are scheduled in.
+func newSitemapTask(baseUrl, outputFile string, entries []SitemapEntry) *SitemapTask {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Url < entries[j].Url
+	})
+
+	return &SitemapTask{
+		BaseUrl:    baseUrl,
+		OutputFile: outputFile,
+		Entries:    entries,
+	}
+}
+
+func (t *SitemapTask) Execute() error {
+	urlSet := sitemapUrlSet{}
+	for _, entry := range t.Entries {
+		url := sitemapUrl{Loc: absoluteUrl(t.BaseUrl, entry.Url)}
+		if !entry.LastMod.IsZero() {
+			url.LastMod = formatLastMod(entry.LastMod)
+		}
+		urlSet.Urls = append(urlSet.Urls, url)
+	}
+
+	content, err := marshalXML(urlSet)
+	if err != nil {
+		return fileError(t.OutputFile, err)
+	}
+
+	return fileError(t.OutputFile, saveFile(content, t.OutputFile))
+}
+
+// Fingerprint implements CacheableTask
+func (t *SitemapTask) Fingerprint() (string, error) {
+	return hashTask(t), nil
+}
+
+func (t *SitemapTask) Name() string {
+	return t.OutputFile
+}
+
+// formatLastMod uses the W3C datetime format, omitting the time when the
+// date has none.
+func formatLastMod(date time.Time) string {
+	if date.Hour() == 0 && date.Minute() == 0 && date.Second() == 0 {
+		return date.Format(time.DateOnly)
+	}
+	return date.Format(time.RFC3339)
+}
+
+// pageLastMod returns when the page was last modified, according to its
+// last-edited-at or published-at dates.
+func pageLastMod(page Page) time.Time {
+	if !page.LastEditedAt.IsZero() {
+		return page.LastEditedAt.Time
+	}
+	return page.PublishedAt.Time
+}
+
+// latestLastMod returns the most recent modification date of the pages
+func latestLastMod(pages []Page) time.Time {
+	latest := time.Time{}
+	for _, page := range pages {
+		lastMod := pageLastMod(page)
+		if lastMod.After(latest) {
+			latest = lastMod
+		}
+	}
+	return latest
+}
+
+// sitemapEnabled reports whether sitemap.xml is generated. It needs the
+// base url of the site, as the sitemap only accepts absolute urls.
+func sitemapEnabled(manifest ManifestFile) bool {
+	return manifest.BaseUrl != "" && (manifest.Sitemap == nil || !manifest.Sitemap.Disabled)
+}
+
+// robotsEnabled reports whether robots.txt is generated. It is enabled by
+// default when base-url is set, or when it is configured explicitly.
+func robotsEnabled(manifest ManifestFile) bool {
+	if manifest.Robots != nil {
+		return !manifest.Robots.Disabled
+	}
+	return manifest.BaseUrl != ""
+}
+
+func isIndexable(page Page) bool {
+	return !contains(page.Flags, noIndexFlag)
+}
+
+// RobotsTask writes robots.txt
+type RobotsTask struct {
+	OutputFile string
+	Content    string
+}
+
+func (t *RobotsTask) Execute() error {
+	return fileError(t.OutputFile, saveFile([]byte(t.Content), t.OutputFile))
+}
+
+// Fingerprint implements CacheableTask
+func (t *RobotsTask) Fingerprint() (string, error) {
+	return hashTask(t), nil
+}
+
+func (t *RobotsTask) Name() string {
+	return t.OutputFile
+}
+
+// robotsContent returns the content of robots.txt. Unless a custom content
+// is configured, every crawler is allowed except for the disallowed paths
+// and the sitemap is referenced.
+func robotsContent(manifest ManifestFile) string {
+	robots := RobotsConfig{}
+	if manifest.Robots != nil {
+		robots = *manifest.Robots
+	}
+
+	if robots.Content != "" {
+		return robots.Content
+	}
+
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	if len(robots.Disallow) == 0 {
+		b.WriteString("Allow: /\n")
+	}
+	for _, path := range robots.Disallow {
+		fmt.Fprintf(&b, "Disallow: %s\n", path)
+	}
+
+	if sitemapEnabled(manifest) {
+		fmt.Fprintf(&b, "\nSitemap: %s\n", absoluteUrl(manifest.BaseUrl, "/sitemap.xml"))
+	}
+
+	return b.String()
+}
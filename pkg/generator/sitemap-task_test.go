@@ -0,0 +1,87 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSitemapTask_ListsGeneratedUrls(t *testing.T) {
+	baseDir := t.TempDir()
+	outDir := t.TempDir()
+	writeContent(t, baseDir, map[string]string{
+		"first.md":  "---\npublished-at: 2024-01-01\nlast-edited-at: 2024-02-01T10:30:00Z\ntags: [go]\n---\n# First",
+		"second.md": "---\npublished-at: 2024-03-01\n---\n# Second",
+		"hidden.md": "---\nflags: [noindex]\n---\n# Hidden",
+	})
+
+	manifest := ManifestFile{
+		BaseUrl:                "https://example.com",
+		HomeTemplate:           "home.html",
+		DefaultSectionTemplate: "section.html",
+		Sections: map[string]Section{
+			"blog": {Pages: []Page{
+				{MarkdownPath: "first.md"},
+				{MarkdownPath: "second.md"},
+				{MarkdownPath: "hidden.md"},
+			}},
+		},
+	}
+
+	tasks, _, err := scheduleTasks(manifest, baseDir, outDir, BuildOptions{})
+	assert.NoError(t, err)
+
+	var sitemap *SitemapTask
+	for _, task := range tasks {
+		if s, ok := task.(*SitemapTask); ok {
+			sitemap = s
+		}
+	}
+	assert.NotNil(t, sitemap)
+	assert.NoError(t, sitemap.Execute())
+
+	content, err := os.ReadFile(filepath.Join(outDir, "sitemap.xml"))
+	assert.NoError(t, err)
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>https://example.com/</loc>
+  </url>
+  <url>
+    <loc>https://example.com/blog/</loc>
+    <lastmod>2024-03-01</lastmod>
+  </url>
+  <url>
+    <loc>https://example.com/blog/first.html</loc>
+    <lastmod>2024-02-01T10:30:00Z</lastmod>
+  </url>
+  <url>
+    <loc>https://example.com/blog/second.html</loc>
+    <lastmod>2024-03-01</lastmod>
+  </url>
+  <url>
+    <loc>https://example.com/blog/tags/go.html</loc>
+    <lastmod>2024-02-01T10:30:00Z</lastmod>
+  </url>
+</urlset>`
+	assert.Equal(t, expected, string(content))
+}
+
+func TestRobotsContent(t *testing.T) {
+	manifest := ManifestFile{BaseUrl: "https://example.com/"}
+	assert.True(t, robotsEnabled(manifest))
+	assert.Equal(t, "User-agent: *\nAllow: /\n\nSitemap: https://example.com/sitemap.xml\n", robotsContent(manifest))
+
+	manifest.Robots = &RobotsConfig{Disallow: []string{"/drafts/"}}
+	manifest.Sitemap = &SitemapConfig{Disabled: true}
+	assert.Equal(t, "User-agent: *\nDisallow: /drafts/\n", robotsContent(manifest))
+
+	manifest.Robots = &RobotsConfig{Content: "User-agent: *\nDisallow: /\n"}
+	assert.Equal(t, "User-agent: *\nDisallow: /\n", robotsContent(manifest))
+
+	assert.False(t, robotsEnabled(ManifestFile{}))
+	assert.False(t, sitemapEnabled(ManifestFile{}))
+}
@@ -0,0 +1,132 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Page fields pages can be sorted by, compared in ascending order
+var pageSortKeys = map[string]func(a, b Page) int{
+	"title": func(a, b Page) int {
+		return strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+	},
+	"published-at": func(a, b Page) int {
+		return a.PublishedAt.Compare(b.PublishedAt.Time)
+	},
+	"last-edited-at": func(a, b Page) int {
+		return pageLastMod(a).Compare(pageLastMod(b))
+	},
+	"weight": func(a, b Page) int {
+		return a.Weight - b.Weight
+	},
+	"path": func(a, b Page) int {
+		return strings.Compare(a.MarkdownPath, b.MarkdownPath)
+	},
+}
+
+// parseSortSpec parses specs like "published-at desc" or "title"
+func parseSortSpec(spec string) (func(a, b Page) int, bool, error) {
+	fields := strings.Fields(spec)
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, false, fmt.Errorf("invalid sort %q, expected \"<field> [asc|desc]\"", spec)
+	}
+
+	compare, ok := pageSortKeys[fields[0]]
+	if !ok {
+		return nil, false, fmt.Errorf("invalid sort field %q, expected one of %s", fields[0], strings.Join(sortKeyNames(), ", "))
+	}
+
+	descending := false
+	if len(fields) == 2 {
+		switch strings.ToLower(fields[1]) {
+		case "asc":
+		case "desc":
+			descending = true
+		default:
+			return nil, false, fmt.Errorf("invalid sort order %q, expected asc or desc", fields[1])
+		}
+	}
+
+	return compare, descending, nil
+}
+
+func sortKeyNames() []string {
+	names := make([]string, 0, len(pageSortKeys))
+	for name := range pageSortKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortPages returns a sorted copy of the pages. The sort is stable, so pages
+// with the same value keep their original order.
+func sortPages(spec string, pages []Page) ([]Page, error) {
+	compare, descending, err := parseSortSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := make([]Page, len(pages))
+	copy(sorted, pages)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if descending {
+			return compare(sorted[i], sorted[j]) > 0
+		}
+		return compare(sorted[i], sorted[j]) < 0
+	})
+
+	return sorted, nil
+}
+
+// PageGroup is a group of pages returned by the groupBy template function
+type PageGroup struct {
+	Key   string
+	Pages []Page
+}
+
+// Functions returning the group of a page
+var pageGroupKeys = map[string]func(page Page) string{
+	"year": func(page Page) string {
+		if page.PublishedAt.IsZero() {
+			return ""
+		}
+		return page.PublishedAt.Format("2006")
+	},
+	"month": func(page Page) string {
+		if page.PublishedAt.IsZero() {
+			return ""
+		}
+		return page.PublishedAt.Format("2006-01")
+	},
+	"section": func(page Page) string {
+		return page.Section
+	},
+}
+
+// groupPages groups pages by year, month or section. Groups are listed in
+// the order their first page appears, so sort the pages before grouping.
+func groupPages(key string, pages []Page) ([]PageGroup, error) {
+	groupKey, ok := pageGroupKeys[key]
+	if !ok {
+		return nil, fmt.Errorf("invalid group %q, expected year, month or section", key)
+	}
+
+	groups := make([]PageGroup, 0)
+	index := make(map[string]int)
+
+	for _, page := range pages {
+		k := groupKey(page)
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, PageGroup{Key: k})
+		}
+		groups[i].Pages = append(groups[i].Pages, page)
+	}
+
+	return groups, nil
+}
@@ -0,0 +1,63 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func titles(pages []Page) []string {
+	result := make([]string, len(pages))
+	for i, page := range pages {
+		result[i] = page.Title
+	}
+	return result
+}
+
+func datedPages() []Page {
+	return []Page{
+		{Title: "b", PublishedAt: mustDate("2023-05-01"), Weight: 2},
+		{Title: "C", PublishedAt: mustDate("2024-01-15"), Weight: 1},
+		{Title: "a", PublishedAt: mustDate("2024-02-01"), Weight: 3},
+	}
+}
+
+func TestSortPages(t *testing.T) {
+	pages := datedPages()
+
+	sorted, err := sortPages("published-at desc", pages)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "C", "b"}, titles(sorted))
+
+	sorted, err = sortPages("title", pages)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "C"}, titles(sorted))
+
+	sorted, err = sortPages("weight asc", pages)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"C", "b", "a"}, titles(sorted))
+
+	// The input is not modified
+	assert.Equal(t, []string{"b", "C", "a"}, titles(pages))
+}
+
+func TestSortPages_InvalidSpec(t *testing.T) {
+	for _, spec := range []string{"", "color", "title sideways", "title asc extra"} {
+		_, err := sortPages(spec, datedPages())
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestGroupPages(t *testing.T) {
+	sorted, _ := sortPages("published-at desc", datedPages())
+
+	groups, err := groupPages("year", sorted)
+	assert.NoError(t, err)
+	assert.Len(t, groups, 2)
+	assert.Equal(t, "2024", groups[0].Key)
+	assert.Equal(t, []string{"a", "C"}, titles(groups[0].Pages))
+	assert.Equal(t, "2023", groups[1].Key)
+
+	_, err = groupPages("color", sorted)
+	assert.Error(t, err)
+}
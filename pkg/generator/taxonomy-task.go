@@ -0,0 +1,135 @@
+package generator
+
+// TermTask renders the pages of a taxonomy term, like the posts tagged "go"
+type TermTask struct {
+	Title          string
+	Taxonomy       string
+	Term           Term
+	Section        string // Empty for site-wide term pages
+	Sections       []string
+	Site           *Site `json:"-"`
+	OutputFile     string
+	Template       string
+	LayoutTemplate string
+	Paginator      *Paginator
+	Metadata       map[string]string
+	Templates      *TemplateRegistry `json:"-"`
+}
+
+// TermData is the data passed to term templates. It has the fields of
+// SectionData, so section templates can render term pages too.
+type TermData struct {
+	Taxonomy   string
+	Term       Term
+	Section    string
+	Metadata   map[string]string
+	Pages      []Page
+	Paginator  *Paginator
+	Taxonomies map[string]Taxonomy
+	Site       *Site
+}
+
+func (t *TermTask) Execute() error {
+	paginator := t.Paginator
+	if paginator == nil {
+		paginator = paginate(t.Term.Pages, 0, t.Term.Url, t.OutputFile, "", "")[0].Paginator
+	}
+
+	data := TermData{
+		Taxonomy:   t.Taxonomy,
+		Term:       t.Term,
+		Section:    t.Section,
+		Metadata:   t.Metadata,
+		Pages:      paginator.Pages,
+		Paginator:  paginator,
+		Taxonomies: t.Site.taxonomies(),
+		Site:       t.Site,
+	}
+
+	return t.Templates.savePageTo(t.OutputFile, t.LayoutTemplate, t.Template, data, PageData{
+		Title:      t.Title,
+		Section:    t.Section,
+		Sections:   t.Sections,
+		Metadata:   t.Metadata,
+		Taxonomies: t.Site.taxonomies(),
+		Site:       t.Site,
+	})
+}
+
+// Fingerprint implements CacheableTask
+func (t *TermTask) Fingerprint() (string, error) {
+	files, err := hashFiles(t.Template, t.LayoutTemplate)
+	if err != nil {
+		return "", err
+	}
+	partials, err := t.Templates.Hash()
+	if err != nil {
+		return "", err
+	}
+	return hashStrings(hashTask(t), t.Site.fingerprint(), hashPages(t.Term.Pages), files, partials), nil
+}
+
+func (t *TermTask) Name() string {
+	return t.OutputFile
+}
+
+// TermsTask renders the page listing every term of a taxonomy
+type TermsTask struct {
+	Title          string
+	Taxonomy       Taxonomy
+	Section        string // Empty for site-wide taxonomies
+	Sections       []string
+	Site           *Site `json:"-"`
+	OutputFile     string
+	Template       string
+	LayoutTemplate string
+	Metadata       map[string]string
+	Templates      *TemplateRegistry `json:"-"`
+}
+
+// TermsData is the data passed to the terms index templates
+type TermsData struct {
+	Taxonomy   Taxonomy
+	Terms      []Term
+	Section    string
+	Metadata   map[string]string
+	Taxonomies map[string]Taxonomy
+	Site       *Site
+}
+
+func (t *TermsTask) Execute() error {
+	data := TermsData{
+		Taxonomy:   t.Taxonomy,
+		Terms:      t.Taxonomy.Terms,
+		Section:    t.Section,
+		Metadata:   t.Metadata,
+		Taxonomies: t.Site.taxonomies(),
+		Site:       t.Site,
+	}
+
+	return t.Templates.savePageTo(t.OutputFile, t.LayoutTemplate, t.Template, data, PageData{
+		Title:      t.Title,
+		Section:    t.Section,
+		Sections:   t.Sections,
+		Metadata:   t.Metadata,
+		Taxonomies: t.Site.taxonomies(),
+		Site:       t.Site,
+	})
+}
+
+// Fingerprint implements CacheableTask
+func (t *TermsTask) Fingerprint() (string, error) {
+	files, err := hashFiles(t.Template, t.LayoutTemplate)
+	if err != nil {
+		return "", err
+	}
+	partials, err := t.Templates.Hash()
+	if err != nil {
+		return "", err
+	}
+	return hashStrings(hashTask(t), t.Site.fingerprint(), hashPages(t.Taxonomy.pages()), files, partials), nil
+}
+
+func (t *TermsTask) Name() string {
+	return t.OutputFile
+}
@@ -0,0 +1,189 @@
+package generator
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+const tagsTaxonomy = "tags"
+
+// Term is a value of a taxonomy, like a tag, with the pages using it
+type Term struct {
+	Name  string
+	Slug  string
+	Url   string // Empty when the term has no page
+	Pages []Page
+}
+
+// Count returns the number of pages using the term
+func (t Term) Count() int {
+	return len(t.Pages)
+}
+
+// Taxonomy holds the terms of a taxonomy, sorted by name
+type Taxonomy struct {
+	Name  string
+	Url   string // Url of the page listing every term, empty when there is none
+	Terms []Term
+}
+
+// Term returns the term with the given name or slug, or an empty term when
+// no page uses it.
+func (t Taxonomy) Term(name string) Term {
+	for _, term := range t.Terms {
+		if term.Name == name || term.Slug == slugify(name) {
+			return term
+		}
+	}
+	return Term{}
+}
+
+// pages returns the pages of every term, a page is repeated for each of its
+// terms
+func (t Taxonomy) pages() []Page {
+	pages := make([]Page, 0)
+	for _, term := range t.Terms {
+		pages = append(pages, term.Pages...)
+	}
+	return pages
+}
+
+// Terms returns the terms of the page for the given taxonomy
+func (p Page) Terms(taxonomy string) []string {
+	if taxonomy == tagsTaxonomy {
+		return p.Tags
+	}
+	return p.Taxonomies[taxonomy]
+}
+
+// siteTaxonomies returns the taxonomies configured in the manifest. Sites
+// that do not configure any only have tags.
+func siteTaxonomies(manifest ManifestFile) map[string]TaxonomyConfig {
+	if manifest.Taxonomies == nil {
+		return map[string]TaxonomyConfig{tagsTaxonomy: {}}
+	}
+	return manifest.Taxonomies
+}
+
+func taxonomyNames(taxonomies map[string]TaxonomyConfig) []string {
+	names := make([]string, 0, len(taxonomies))
+	for name := range taxonomies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// collectTerms groups the pages by their terms of the taxonomy. Terms with
+// the same slug, like "Go" and "go", are the same term. urlDir is the url
+// path the term pages are generated in, empty when there are none.
+func collectTerms(taxonomy string, pages []Page, urlDir string) Taxonomy {
+	terms := make([]Term, 0)
+	index := make(map[string]int)
+
+	for _, page := range pages {
+		for _, name := range page.Terms(taxonomy) {
+			slug := slugify(name)
+			if slug == "" {
+				continue
+			}
+
+			i, ok := index[slug]
+			if !ok {
+				i = len(terms)
+				index[slug] = i
+				terms = append(terms, Term{Name: name, Slug: slug})
+				if urlDir != "" {
+					terms[i].Url = path.Join("/", urlDir, slug+".html")
+				}
+			}
+			terms[i].Pages = append(terms[i].Pages, page)
+		}
+	}
+
+	sort.SliceStable(terms, func(i, j int) bool {
+		return strings.ToLower(terms[i].Name) < strings.ToLower(terms[j].Name)
+	})
+
+	return Taxonomy{Name: taxonomy, Terms: terms}
+}
+
+// termListing describes the term pages of a taxonomy, either for the pages
+// of a section or site-wide.
+type termListing struct {
+	Taxonomy Taxonomy
+	Config   TaxonomyConfig
+	Section  string
+	Dir      string // Url path the term pages are generated in
+	Paginate int
+	Metadata map[string]string
+}
+
+// termTemplate returns the template of the term pages, or an empty string
+// when they are not generated.
+func termTemplate(manifest ManifestFile, config TaxonomyConfig) string {
+	if config.Template != "" {
+		return config.Template
+	}
+	return manifest.DefaultSectionTemplate
+}
+
+// termTasks schedules the term pages and the terms index of a listing,
+// returning the sitemap entries of the generated pages.
+func termTasks(manifest ManifestFile, templates *TemplateRegistry, baseDir, outDir string, sections []string, site *Site, listing termListing) ([]Task, []SitemapEntry) {
+	tasks := make([]Task, 0)
+	sitemap := make([]SitemapEntry, 0)
+
+	if listing.Config.IndexTemplate != "" {
+		url := path.Join("/", listing.Dir) + "/"
+		sitemap = append(sitemap, SitemapEntry{Url: url})
+
+		tasks = append(tasks, &TermsTask{
+			Title:          manifest.Title,
+			Taxonomy:       listing.Taxonomy,
+			Section:        listing.Section,
+			Sections:       sections,
+			Site:           site,
+			OutputFile:     getFullPath(outDir, path.Join(url, "index.html")),
+			Template:       getFullPath(baseDir, listing.Config.IndexTemplate),
+			LayoutTemplate: getFullPath(baseDir, manifest.DefaultLayoutTemplate),
+			Metadata:       listing.Metadata,
+			Templates:      templates,
+		})
+	}
+
+	template := termTemplate(manifest, listing.Config)
+	if template == "" {
+		return tasks, sitemap
+	}
+
+	for _, term := range listing.Taxonomy.Terms {
+		outputFile := getFullPath(outDir, term.Url)
+		pagesDir := path.Join(listing.Dir, term.Slug)
+
+		for _, listingPage := range paginate(term.Pages, listing.Paginate, term.Url, outputFile, pagesDir, outDir) {
+			sitemap = append(sitemap, SitemapEntry{
+				Url:     listingPage.Url,
+				LastMod: latestLastMod(listingPage.Paginator.Pages),
+			})
+
+			tasks = append(tasks, &TermTask{
+				Title:          manifest.Title,
+				Taxonomy:       listing.Taxonomy.Name,
+				Term:           term,
+				Section:        listing.Section,
+				Sections:       sections,
+				Site:           site,
+				OutputFile:     listingPage.OutputFile,
+				Template:       getFullPath(baseDir, template),
+				LayoutTemplate: getFullPath(baseDir, manifest.DefaultLayoutTemplate),
+				Paginator:      listingPage.Paginator,
+				Metadata:       listing.Metadata,
+				Templates:      templates,
+			})
+		}
+	}
+
+	return tasks, sitemap
+}
@@ -0,0 +1,126 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectTerms(t *testing.T) {
+	pages := []Page{
+		{Title: "a", Tags: []string{"Go", "testing"}},
+		{Title: "b", Tags: []string{"go"}, Taxonomies: map[string][]string{"authors": {"Jane Doe"}}},
+		{Title: "c", Tags: []string{"Apis"}},
+	}
+
+	tags := collectTerms("tags", pages, "blog/tags")
+	assert.Equal(t, "tags", tags.Name)
+	assert.Len(t, tags.Terms, 3)
+
+	// Sorted by name, terms with the same slug are merged
+	assert.Equal(t, "Apis", tags.Terms[0].Name)
+	assert.Equal(t, "Go", tags.Terms[1].Name)
+	assert.Equal(t, "go", tags.Terms[1].Slug)
+	assert.Equal(t, 2, tags.Terms[1].Count())
+	assert.Equal(t, []string{"a", "b"}, titles(tags.Terms[1].Pages))
+	assert.Equal(t, "/blog/tags/testing.html", tags.Terms[2].Url)
+
+	assert.Equal(t, "Go", tags.Term("go").Name)
+	assert.Equal(t, 0, tags.Term("missing").Count())
+
+	authors := collectTerms("authors", pages, "")
+	assert.Len(t, authors.Terms, 1)
+	assert.Equal(t, "jane-doe", authors.Terms[0].Slug)
+	assert.Empty(t, authors.Terms[0].Url)
+}
+
+func TestPageTermsInTemplates(t *testing.T) {
+	dir := t.TempDir()
+	writeContent(t, dir, map[string]string{
+		"partials/terms.html": `{{ .page.Title }}:{{ range .page.Terms "tags" }}{{ . }},{{ end }}{{ range .page.Terms "authors" }}{{ . }}{{ end }};`,
+		"list.html":           `{{ range .Pages }}{{ template "terms" (dict "page" .) }}{{ end }}`,
+	})
+
+	templates, err := newTemplateRegistry(filepath.Join(dir, "partials"), templateFuncs("", time.Now()))
+	assert.NoError(t, err)
+
+	// Pages passed around in templates are values, not pointers
+	html, err := templates.Render("out.html", "", filepath.Join(dir, "list.html"), SectionData{Pages: []Page{
+		{Title: "a", Tags: []string{"go", "testing"}},
+		{Title: "b", Taxonomies: map[string][]string{"authors": {"Jane"}}},
+	}}, PageData{})
+	assert.NoError(t, err)
+	assert.Equal(t, "a:go,testing,;b:Jane;", string(html))
+}
+
+func TestScheduleTaxonomyTasks(t *testing.T) {
+	dir := t.TempDir()
+	writeContent(t, dir, map[string]string{
+		"posts/one.md":   "---\ntitle: One\ntags: [go]\ntaxonomies:\n  categories: [Backend]\n---\n# One",
+		"posts/two.md":   "---\ntitle: Two\ntags: [go, web]\n---\n# Two",
+		"notes/three.md": "---\ntitle: Three\ntaxonomies:\n  categories: [Backend]\n---\n# Three",
+		"layout.html":    "{{ .HTML }}",
+		"section.html":   "section",
+		"term.html":      "{{ .Term.Name }}:{{ range .Pages }}{{ .Title }};{{ end }}",
+		"terms.html":     "{{ range .Terms }}{{ .Name }}={{ .Count }};{{ end }}",
+	})
+
+	manifest := ManifestFile{
+		Title:                  "Site",
+		DefaultLayoutTemplate:  "layout.html",
+		DefaultSectionTemplate: "section.html",
+		Sections: map[string]Section{
+			"posts": {ContentDir: "posts"},
+			"notes": {ContentDir: "notes"},
+		},
+		Taxonomies: map[string]TaxonomyConfig{
+			"tags": {},
+			"categories": {
+				Template:      "term.html",
+				IndexTemplate: "terms.html",
+				SiteWide:      true,
+			},
+		},
+	}
+
+	outDir := t.TempDir()
+	tasks, _, err := scheduleTasks(manifest, dir, outDir, BuildOptions{})
+	assert.NoError(t, err)
+
+	outputs := []string{}
+	for _, task := range tasks {
+		switch task := task.(type) {
+		case *TermTask, *TermsTask:
+			rel, _ := filepath.Rel(outDir, task.Name())
+			outputs = append(outputs, filepath.ToSlash(rel))
+			assert.NoError(t, task.Execute())
+		}
+	}
+
+	assert.ElementsMatch(t, []string{
+		"posts/tags/go.html",
+		"posts/tags/web.html",
+		"posts/categories/index.html",
+		"posts/categories/backend.html",
+		"notes/categories/index.html",
+		"notes/categories/backend.html",
+		"categories/index.html",
+		"categories/backend.html",
+	}, outputs)
+
+	content, err := os.ReadFile(filepath.Join(outDir, "categories", "backend.html"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Backend:Three;One;", string(content))
+
+	content, err = os.ReadFile(filepath.Join(outDir, "categories", "index.html"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Backend=2;", string(content))
+
+	// Tags fall back to the section template
+	content, err = os.ReadFile(filepath.Join(outDir, "posts", "tags", "go.html"))
+	assert.NoError(t, err)
+	assert.Equal(t, "section", string(content))
+}
@@ -1,7 +1,17 @@
 package generator
 
 import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/url"
+	"path"
+	"regexp"
 	"strings"
+	"time"
+	"unicode"
+
+	"github.com/saasuke-labs/gengo/pkg/parser"
 )
 
 // wherePages filters pages based on flag conditions
@@ -61,3 +71,237 @@ func contains(slice []string, item string) bool {
 	}
 	return false
 }
+
+// reversePages returns the pages in reverse order
+func reversePages(pages []Page) []Page {
+	reversed := make([]Page, len(pages))
+	for i, page := range pages {
+		reversed[len(pages)-1-i] = page
+	}
+	return reversed
+}
+
+// firstPages returns the first n pages
+// Syntax: {{ .Pages | sortBy "published-at desc" | first 5 }}
+func firstPages(n int, pages []Page) []Page {
+	if n < 0 {
+		n = 0
+	}
+	if n > len(pages) {
+		n = len(pages)
+	}
+	return pages[:n]
+}
+
+// templateFuncs returns the functions available in every template. baseUrl
+// is the base url of the site, used by absURL and relURL. now returns
+// buildTime, so every page of a build shows the same time, like
+// .Site.BuildTime.
+func templateFuncs(baseUrl string, buildTime time.Time) template.FuncMap {
+	return template.FuncMap{
+		// Pages
+		"where":   wherePages,
+		"sortBy":  sortPages,
+		"reverse": reversePages,
+		"first":   firstPages,
+		"limit":   firstPages,
+		"groupBy": groupPages,
+
+		// Dates
+		"now":        func() time.Time { return buildTime },
+		"dateFormat": dateFormat,
+
+		// Content
+		"markdownify": parser.Markdownify,
+		"plainify":    plainify,
+		"truncate":    truncate,
+		"slugify":     slugify,
+		"jsonify":     jsonify,
+		"safeHTML":    func(s string) template.HTML { return template.HTML(s) },
+		"safeURL":     func(s string) template.URL { return template.URL(s) },
+		"safeCSS":     func(s string) template.CSS { return template.CSS(s) },
+		"safeJS":      func(s string) template.JS { return template.JS(s) },
+
+		// Urls
+		"absURL": func(p string) string { return absURL(baseUrl, p) },
+		"relURL": func(p string) string { return relURL(baseUrl, p) },
+
+		// Strings
+		"upper":     strings.ToUpper,
+		"lower":     strings.ToLower,
+		"title":     titleCase,
+		"trim":      strings.TrimSpace,
+		"replace":   func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"contains":  func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix": func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix": func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"split":     func(sep, s string) []string { return strings.Split(s, sep) },
+		"join":      func(sep string, values []string) string { return strings.Join(values, sep) },
+		"default":   defaultValue,
+
+		// Math
+		"add": func(a, b int) int { return a + b },
+		"sub": func(a, b int) int { return a - b },
+		"mul": func(a, b int) int { return a * b },
+		"div": divide,
+		"mod": modulo,
+
+		// Collections
+		"dict": dict,
+		"list": list,
+	}
+}
+
+// dateFormat formats a date with a Go layout. It accepts dates from the
+// manifest and front matter, time values and strings.
+// Syntax: {{ .PublishedAt | dateFormat "Jan 2, 2006" }}
+func dateFormat(layout string, value interface{}) (string, error) {
+	switch date := value.(type) {
+	case Date:
+		if date.IsZero() {
+			return "", nil
+		}
+		return date.Format(layout), nil
+	case time.Time:
+		return date.Format(layout), nil
+	case string:
+		if date == "" {
+			return "", nil
+		}
+		parsed, err := parseDate(date)
+		if err != nil {
+			return "", err
+		}
+		return parsed.Format(layout), nil
+	default:
+		return "", fmt.Errorf("dateFormat: unsupported date %T", value)
+	}
+}
+
+var htmlTagRegex = regexp.MustCompile(`<[^>]*>`)
+
+// plainify strips the html tags
+func plainify(value interface{}) string {
+	return htmlTagRegex.ReplaceAllString(fmt.Sprint(value), "")
+}
+
+// truncate shortens the text to length characters, ending it with an
+// ellipsis when cut. Html is stripped first, so no tag is left open.
+// Syntax: {{ .Description | truncate 140 }}
+func truncate(length int, value interface{}) string {
+	text := []rune(strings.TrimSpace(plainify(value)))
+	if len(text) <= length {
+		return string(text)
+	}
+	return strings.TrimRightFunc(string(text[:max(length, 0)]), unicode.IsSpace) + "…"
+}
+
+// jsonify encodes the value as json, e.g. for json-ld script tags
+func jsonify(value interface{}) (template.JS, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return template.JS(data), nil
+}
+
+func isAbsoluteUrl(p string) bool {
+	u, err := url.Parse(p)
+	return err == nil && u.IsAbs()
+}
+
+// absURL returns the absolute url of a path of the site
+func absURL(baseUrl, p string) string {
+	if isAbsoluteUrl(p) || baseUrl == "" {
+		return relURL(baseUrl, p)
+	}
+	return absoluteUrl(baseUrl, relURL("", p))
+}
+
+// relURL returns the path of a page of the site, prefixed with the path of
+// the base url for sites not served from the root of their domain.
+func relURL(baseUrl, p string) string {
+	if isAbsoluteUrl(p) {
+		return p
+	}
+
+	basePath := ""
+	if u, err := url.Parse(baseUrl); err == nil {
+		basePath = u.Path
+	}
+
+	joined := path.Join("/", basePath, p)
+	if strings.HasSuffix(p, "/") && joined != "/" {
+		joined += "/"
+	}
+	return joined
+}
+
+// titleCase capitalizes the first letter of every word
+func titleCase(s string) string {
+	var b strings.Builder
+	startOfWord := true
+	for _, r := range s {
+		if startOfWord {
+			r = unicode.ToTitle(r)
+		}
+		b.WriteRune(r)
+		startOfWord = unicode.IsSpace(r)
+	}
+	return b.String()
+}
+
+// defaultValue returns value, or def when value is empty
+// Syntax: {{ .Description | default "No description" }}
+func defaultValue(def, value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return def
+	case string:
+		if v == "" {
+			return def
+		}
+	case []string:
+		if len(v) == 0 {
+			return def
+		}
+	}
+	return value
+}
+
+func divide(a, b int) (int, error) {
+	if b == 0 {
+		return 0, fmt.Errorf("div: division by zero")
+	}
+	return a / b, nil
+}
+
+func modulo(a, b int) (int, error) {
+	if b == 0 {
+		return 0, fmt.Errorf("mod: division by zero")
+	}
+	return a % b, nil
+}
+
+// dict builds a map from key and value pairs, used to pass several values
+// to a partial. Syntax: {{ template "card.html" (dict "title" .Title "url" .Url) }}
+func dict(pairs ...interface{}) (map[string]interface{}, error) {
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("dict: expected key and value pairs, got %d values", len(pairs))
+	}
+
+	result := make(map[string]interface{}, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("dict: key %v is not a string", pairs[i])
+		}
+		result[key] = pairs[i+1]
+	}
+	return result, nil
+}
+
+// list builds a list from its arguments
+func list(values ...interface{}) []interface{} {
+	return values
+}
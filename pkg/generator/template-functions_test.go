@@ -1,7 +1,10 @@
 package generator
 
 import (
+	"html/template"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -171,3 +174,94 @@ func TestContains(t *testing.T) {
 	assert.False(t, contains(slice, "notfound"))
 	assert.False(t, contains([]string{}, "pinned"))
 }
+
+func TestReversePages(t *testing.T) {
+	pages := []Page{{Title: "Page 1"}, {Title: "Page 2"}, {Title: "Page 3"}}
+
+	result := reversePages(pages)
+	assert.Equal(t, "Page 3", result[0].Title)
+	assert.Equal(t, "Page 1", result[2].Title)
+	assert.Equal(t, "Page 1", pages[0].Title)
+}
+
+func TestFirstPages(t *testing.T) {
+	pages := []Page{{Title: "Page 1"}, {Title: "Page 2"}, {Title: "Page 3"}}
+
+	assert.Len(t, firstPages(2, pages), 2)
+	assert.Len(t, firstPages(10, pages), 3)
+	assert.Len(t, firstPages(-1, pages), 0)
+}
+
+func TestDateFormat(t *testing.T) {
+	formatted, err := dateFormat("Jan 2, 2006", mustDate("2024-03-05"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Mar 5, 2024", formatted)
+
+	formatted, err = dateFormat("2006", "2023-01-01T10:00:00Z")
+	assert.NoError(t, err)
+	assert.Equal(t, "2023", formatted)
+
+	formatted, err = dateFormat("2006", Date{})
+	assert.NoError(t, err)
+	assert.Empty(t, formatted)
+
+	_, err = dateFormat("2006", 42)
+	assert.Error(t, err)
+}
+
+func TestTextFunctions(t *testing.T) {
+	assert.Equal(t, "Hello world", plainify(template.HTML("<p>Hello <b>world</b></p>")))
+	assert.Equal(t, "Hello…", truncate(6, "<p>Hello world</p>"))
+	assert.Equal(t, "Hello", truncate(10, "Hello"))
+	assert.Equal(t, "Hello World", titleCase("hello world"))
+	assert.Equal(t, "fallback", defaultValue("fallback", ""))
+	assert.Equal(t, "value", defaultValue("fallback", "value"))
+
+	json, err := jsonify(map[string]interface{}{"title": "A <b>"})
+	assert.NoError(t, err)
+	// Html characters are escaped, so the json is safe inside script tags
+	assert.Equal(t, template.JS(`{"title":"A \u003cb\u003e"}`), json)
+
+	_, err = divide(1, 0)
+	assert.Error(t, err)
+}
+
+func TestUrlFunctions(t *testing.T) {
+	assert.Equal(t, "https://example.com/blog/post.html", absURL("https://example.com", "/blog/post.html"))
+	assert.Equal(t, "https://example.com/docs/blog/", absURL("https://example.com/docs/", "blog/"))
+	assert.Equal(t, "/docs/blog/", relURL("https://example.com/docs/", "blog/"))
+	assert.Equal(t, "/blog/post.html", relURL("", "blog/post.html"))
+	assert.Equal(t, "/", relURL("https://example.com", "/"))
+	assert.Equal(t, "https://other.com/a", absURL("https://example.com", "https://other.com/a"))
+	assert.Equal(t, "/a", absURL("", "a"))
+}
+
+func TestDictAndList(t *testing.T) {
+	values, err := dict("title", "Hello", "count", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"title": "Hello", "count": 2}, values)
+
+	_, err = dict("title")
+	assert.Error(t, err)
+	_, err = dict(1, "value")
+	assert.Error(t, err)
+
+	assert.Equal(t, []interface{}{"a", 1}, list("a", 1))
+}
+
+func TestTemplateFuncsInTemplates(t *testing.T) {
+	dir := t.TempDir()
+	writeContent(t, dir, map[string]string{
+		"partials/Card.html": `<div>{{ .title }} {{ .count }}</div>`,
+		"home.html":          `{{ template "Card.html" (dict "title" ("go tips" | title) "count" (add 1 2)) }}{{ "a *b*" | markdownify }} {{ absURL "/feed.xml" }} {{ now.Year }}`,
+	})
+
+	// now is the build time, not the time each page is rendered at
+	buildTime := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+	templates, err := newTemplateRegistry(filepath.Join(dir, "partials"), templateFuncs("https://example.com", buildTime))
+	assert.NoError(t, err)
+
+	html, err := templates.Render("out.html", "", filepath.Join(dir, "home.html"), nil, PageData{})
+	assert.NoError(t, err)
+	assert.Equal(t, `<div>Go Tips 3</div>a <em>b</em> https://example.com/feed.xml 2020`, string(html))
+}
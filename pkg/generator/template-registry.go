@@ -0,0 +1,272 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template/parse"
+	"time"
+)
+
+// Directory with the partials, relative to the manifest, when the manifest
+// does not configure one
+const defaultPartialsDir = "partials"
+
+// TemplateRegistry parses the templates of a build once and shares them
+// between tasks. Every template can include the partials by their path
+// relative to the partials directory, with or without the extension, e.g.
+// {{ template "header.html" . }} or {{ template "header" . }}.
+//
+// Content templates (page, section, term...) are parsed together with the
+// layout, so they can override the layout blocks:
+//
+//	layout.html: <main>{{ block "main" . }}{{ .HTML }}{{ end }}</main>
+//	page.html:   {{ define "main" }}<article>{{ .HTML }}</article>{{ end }}
+//
+// The overrides are rendered with the data of the content template, e.g.
+// the pages of a section, not with the data of the layout.
+type TemplateRegistry struct {
+	base     *template.Template
+	partials []string
+
+	mu   sync.Mutex
+	sets map[string]*templateSet
+}
+
+// templateSet is a layout parsed with a content template
+type templateSet struct {
+	*template.Template
+	// blocks are the templates defined by the content template. They are
+	// replaced by calls to contentBlockFunc, which renders the definitions
+	// of the content template, kept under contentBlockPrefix, with the
+	// content data.
+	blocks []string
+}
+
+const (
+	contentBlockFunc   = "gengoContentBlock"
+	contentBlockPrefix = "gengo-content-"
+)
+
+func newTemplateSet(funcs template.FuncMap) *template.Template {
+	return template.New("").Funcs(funcs)
+}
+
+// newTemplateRegistry loads the partials from partialsDir. A missing
+// directory means the site has no partials. funcs are available in every
+// template.
+func newTemplateRegistry(partialsDir string, funcs template.FuncMap) (*TemplateRegistry, error) {
+	r := &TemplateRegistry{
+		base: newTemplateSet(funcs),
+		sets: make(map[string]*templateSet),
+	}
+
+	if partialsDir == "" {
+		return r, nil
+	}
+	if _, err := os.Stat(partialsDir); os.IsNotExist(err) {
+		return r, nil
+	}
+
+	err := filepath.WalkDir(partialsDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".html" {
+			return nil
+		}
+		r.partials = append(r.partials, path)
+		return nil
+	})
+	if err != nil {
+		return nil, fileError(partialsDir, err)
+	}
+	sort.Strings(r.partials)
+
+	for _, path := range r.partials {
+		name, err := filepath.Rel(partialsDir, path)
+		if err != nil {
+			return nil, fileError(path, err)
+		}
+		name = filepath.ToSlash(name)
+		if err := parseTemplateFile(r.base, name, path); err != nil {
+			return nil, templateError(path, path, err)
+		}
+
+		// Partials are also included without the extension, as the
+		// components written in GSX do: {{ template "Card" . }}
+		alias := strings.TrimSuffix(name, ".html")
+		if r.base.Lookup(alias) == nil {
+			if _, err := r.base.AddParseTree(alias, r.base.Lookup(name).Tree); err != nil {
+				return nil, templateError(path, path, err)
+			}
+		}
+	}
+
+	return r, nil
+}
+
+// partialsDir returns the partials directory configured in the manifest
+func partialsDir(manifest ManifestFile, baseDir string) string {
+	if manifest.PartialsDir != "" {
+		return getFullPath(baseDir, manifest.PartialsDir)
+	}
+	return getFullPath(baseDir, defaultPartialsDir)
+}
+
+func parseTemplateFile(set *template.Template, name, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	_, err = set.New(name).Parse(string(content))
+	return err
+}
+
+// Hash returns the hash of the partials, as a change in any of them may
+// change every page.
+func (r *TemplateRegistry) Hash() (string, error) {
+	if r == nil {
+		return "", nil
+	}
+	return hashFiles(r.partials...)
+}
+
+// set returns the template set with the layout and content templates. Sets
+// are cached, so each combination is parsed only once per build. A nil
+// registry parses the templates on every call.
+func (r *TemplateRegistry) set(file, layoutPath, contentPath string) (*templateSet, error) {
+	key := layoutPath + "\x00" + contentPath
+
+	base := newTemplateSet(templateFuncs("", time.Now()))
+	if r != nil {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if set, ok := r.sets[key]; ok {
+			return set, nil
+		}
+		base = r.base
+	}
+
+	set, err := base.Clone()
+	if err != nil {
+		return nil, templateError(file, layoutPath, err)
+	}
+
+	if layoutPath != "" {
+		if err := parseTemplateFile(set, layoutPath, layoutPath); err != nil {
+			return nil, templateError(file, layoutPath, err)
+		}
+	}
+
+	blocks := make([]string, 0)
+	if contentPath != "" {
+		layout := make(map[string]*parse.Tree)
+		for _, t := range set.Templates() {
+			layout[t.Name()] = t.Tree
+		}
+
+		if err := parseTemplateFile(set, contentPath, contentPath); err != nil {
+			return nil, templateError(file, contentPath, err)
+		}
+
+		for _, t := range set.Templates() {
+			if t.Name() != contentPath && t.Tree != layout[t.Name()] {
+				blocks = append(blocks, t.Name())
+			}
+		}
+		sort.Strings(blocks)
+	}
+
+	if len(blocks) > 0 {
+		// Replaced on every render by the function rendering the block
+		set.Funcs(template.FuncMap{contentBlockFunc: func(string) template.HTML { return "" }})
+	}
+	for _, name := range blocks {
+		if _, err := set.AddParseTree(contentBlockPrefix+name, set.Lookup(name).Tree); err != nil {
+			return nil, templateError(file, contentPath, err)
+		}
+		if _, err := set.New(name).Parse(fmt.Sprintf("{{ %s %q }}", contentBlockFunc, name)); err != nil {
+			return nil, templateError(file, contentPath, err)
+		}
+	}
+
+	cached := &templateSet{Template: set, blocks: blocks}
+	if r != nil {
+		r.sets[key] = cached
+	}
+	return cached, nil
+}
+
+// Render executes the content template with data and then the layout, with
+// the rendered content as .HTML. Without content template, layoutData.HTML
+// is rendered as is. file is only used to give context to the errors.
+func (r *TemplateRegistry) Render(file, layoutPath, contentPath string, data interface{}, layoutData PageData) (template.HTML, error) {
+	cached, err := r.set(file, layoutPath, contentPath)
+	if err != nil {
+		return "", err
+	}
+
+	set := cached.Template
+	var blockErr error
+	if len(cached.blocks) > 0 {
+		// Sets are shared between tasks, each render binds its own data
+		set, err = set.Clone()
+		if err != nil {
+			return "", templateError(file, contentPath, err)
+		}
+		set.Funcs(template.FuncMap{contentBlockFunc: func(name string) (template.HTML, error) {
+			html := bytes.NewBufferString("")
+			if err := set.ExecuteTemplate(html, contentBlockPrefix+name, data); err != nil {
+				blockErr = err
+				return "", err
+			}
+			return template.HTML(html.String()), nil
+		}})
+	}
+
+	if contentPath != "" {
+		html := bytes.NewBufferString("")
+		if err := set.ExecuteTemplate(html, contentPath, data); err != nil {
+			if blockErr != nil {
+				err = blockErr
+			}
+			return "", templateError(file, contentPath, err)
+		}
+		// Templates only defining blocks have no content of their own
+		if strings.TrimSpace(html.String()) != "" || layoutData.HTML == "" {
+			layoutData.HTML = template.HTML(html.String())
+		}
+	}
+
+	if layoutPath == "" {
+		return layoutData.HTML, nil
+	}
+
+	html := bytes.NewBufferString("")
+	if err := set.ExecuteTemplate(html, layoutPath, layoutData); err != nil {
+		// Errors of the blocks point to the content template
+		if blockErr != nil {
+			return "", templateError(file, contentPath, blockErr)
+		}
+		return "", templateError(file, layoutPath, err)
+	}
+
+	return template.HTML(html.String()), nil
+}
+
+// savePageTo renders the page with the templates and saves it to outputFile
+func (r *TemplateRegistry) savePageTo(outputFile, layoutPath, contentPath string, data interface{}, layoutData PageData) error {
+	html, err := r.Render(outputFile, layoutPath, contentPath, data, layoutData)
+	if err != nil {
+		return err
+	}
+
+	return fileError(outputFile, savePage(html, outputFile))
+}
@@ -0,0 +1,167 @@
+package generator
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateRegistryPartials(t *testing.T) {
+	dir := t.TempDir()
+	writeContent(t, dir, map[string]string{
+		"partials/header.html":   `<header>{{ .Title }}</header>`,
+		"partials/nav/menu.html": `<nav>{{ range .Sections }}{{ . }};{{ end }}</nav>`,
+		"partials/notes.txt":     `not a template`,
+		"layout.html":            `{{ template "header.html" . }}{{ template "nav/menu.html" . }}{{ .HTML }}`,
+		"short.html":             `{{ template "header" . }}{{ template "nav/menu" . }}`,
+	})
+
+	templates, err := newTemplateRegistry(filepath.Join(dir, "partials"), templateFuncs("", time.Now()))
+	assert.NoError(t, err)
+
+	html, err := templates.Render("out.html", filepath.Join(dir, "layout.html"), "", nil, PageData{
+		Title:    "Site",
+		Sections: []string{"blog", "notes"},
+		HTML:     "<p>content</p>",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "<header>Site</header><nav>blog;notes;</nav><p>content</p>", string(html))
+
+	// Partials are also named without the extension
+	html, err = templates.Render("out.html", filepath.Join(dir, "short.html"), "", nil, PageData{
+		Title:    "Site",
+		Sections: []string{"blog"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "<header>Site</header><nav>blog;</nav>", string(html))
+
+	hash, err := templates.Hash()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, hash)
+}
+
+func TestTemplateRegistryMissingPartials(t *testing.T) {
+	templates, err := newTemplateRegistry(filepath.Join(t.TempDir(), "partials"), templateFuncs("", time.Now()))
+	assert.NoError(t, err)
+
+	hash, err := templates.Hash()
+	assert.NoError(t, err)
+	assert.Equal(t, hashStrings(), hash)
+}
+
+func TestTemplateRegistryBlocks(t *testing.T) {
+	dir := t.TempDir()
+	writeContent(t, dir, map[string]string{
+		"layout.html":  `<title>{{ block "title" . }}{{ .Title }}{{ end }}</title><main>{{ block "main" . }}{{ .HTML }}{{ end }}</main>`,
+		"page.html":    `<article>{{ .HTML }}</article>`,
+		"blocks.html":  `{{ define "title" }}Custom {{ .Title }}{{ end }}`,
+		"content.html": `{{ define "main" }}<section>{{ .HTML }}</section>{{ end }}`,
+	})
+
+	templates, err := newTemplateRegistry("", templateFuncs("", time.Now()))
+	assert.NoError(t, err)
+
+	render := func(content string) string {
+		data := PageData{Title: "Post", HTML: "<p>body</p>"}
+		html, err := templates.Render("out.html", filepath.Join(dir, "layout.html"), content, data, data)
+		assert.NoError(t, err)
+		return string(html)
+	}
+
+	// Templates with content are rendered into the default blocks
+	assert.Equal(t, "<title>Post</title><main><article><p>body</p></article></main>", render(filepath.Join(dir, "page.html")))
+
+	// Templates defining blocks override them, keeping the rest of the layout
+	assert.Equal(t, "<title>Custom Post</title><main><p>body</p></main>", render(filepath.Join(dir, "blocks.html")))
+	assert.Equal(t, "<title>Post</title><main><section><p>body</p></section></main>", render(filepath.Join(dir, "content.html")))
+
+	// Overrides do not leak between templates sharing the layout
+	assert.Equal(t, "<title>Post</title><main><p>body</p></main>", render(""))
+}
+
+func TestTemplateRegistryBlocksUseContentData(t *testing.T) {
+	dir := t.TempDir()
+	writeContent(t, dir, map[string]string{
+		"layout.html":  `<h1>{{ .Title }}</h1><main>{{ block "main" . }}{{ .HTML }}{{ end }}</main>`,
+		"section.html": `{{ define "main" }}{{ range .Pages }}<a>{{ .Title }}</a>{{ end }}{{ end }}`,
+		"page.html":    `{{ define "main" }}{{ .ExternalData.stars }} stars{{ end }}`,
+		"broken.html":  "{{ define \"main\" }}\n{{ .Missing }}{{ end }}",
+	})
+
+	templates, err := newTemplateRegistry("", templateFuncs("", time.Now()))
+	assert.NoError(t, err)
+
+	layout := filepath.Join(dir, "layout.html")
+	layoutData := PageData{Title: "Site"}
+
+	html, err := templates.Render("blog/index.html", layout, filepath.Join(dir, "section.html"), SectionData{
+		Pages: []Page{{Title: "First"}, {Title: "Second"}},
+	}, layoutData)
+	assert.NoError(t, err)
+	assert.Equal(t, "<h1>Site</h1><main><a>First</a><a>Second</a></main>", string(html))
+
+	html, err = templates.Render("blog/post.html", layout, filepath.Join(dir, "page.html"), PageData{
+		ExternalData: map[string]interface{}{"stars": 42},
+	}, layoutData)
+	assert.NoError(t, err)
+	assert.Equal(t, "<h1>Site</h1><main>42 stars</main>", string(html))
+
+	// Errors of the blocks point to the content template
+	_, err = templates.Render("blog/post.html", layout, filepath.Join(dir, "broken.html"), SectionData{}, layoutData)
+	var buildErr *BuildError
+	assert.True(t, errors.As(err, &buildErr))
+	assert.Equal(t, filepath.Join(dir, "broken.html"), buildErr.Template)
+	assert.Equal(t, 2, buildErr.Line)
+}
+
+func TestTemplateRegistryErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeContent(t, dir, map[string]string{
+		"partials/broken.html": "{{ if }}",
+	})
+
+	_, err := newTemplateRegistry(filepath.Join(dir, "partials"), templateFuncs("", time.Now()))
+	var buildErr *BuildError
+	assert.True(t, errors.As(err, &buildErr))
+	assert.Equal(t, filepath.Join(dir, "partials", "broken.html"), buildErr.Template)
+	assert.Equal(t, 1, buildErr.Line)
+}
+
+func TestScheduleTemplateOverrides(t *testing.T) {
+	dir := t.TempDir()
+	writeContent(t, dir, map[string]string{
+		"posts/default.md": "# Default",
+		"posts/custom.md":  "---\ntemplate: custom.html\nlayout: bare.html\n---\n# Custom",
+	})
+
+	manifest := ManifestFile{
+		DefaultLayoutTemplate:  "layout.html",
+		DefaultPageTemplate:    "page.html",
+		DefaultSectionTemplate: "section.html",
+		Sections: map[string]Section{
+			"posts": {ContentDir: "posts", Template: "posts.html", PageTemplate: "post.html"},
+			"notes": {},
+		},
+	}
+
+	tasks, _, err := scheduleTasks(manifest, dir, t.TempDir(), BuildOptions{})
+	assert.NoError(t, err)
+
+	pageTemplates := map[string][2]string{}
+	sectionTemplates := map[string]string{}
+	for _, task := range tasks {
+		switch task := task.(type) {
+		case *PageTask:
+			pageTemplates[task.Url] = [2]string{filepath.Base(task.Template), filepath.Base(task.LayoutTemplate)}
+		case *SectionTask:
+			sectionTemplates[task.Section] = filepath.Base(task.Template)
+		}
+	}
+
+	assert.Equal(t, [2]string{"post.html", "layout.html"}, pageTemplates["/posts/default.html"])
+	assert.Equal(t, [2]string{"custom.html", "bare.html"}, pageTemplates["/posts/custom.html"])
+	assert.Equal(t, map[string]string{"posts": "posts.html", "notes": "section.html"}, sectionTemplates)
+}
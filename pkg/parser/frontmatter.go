@@ -0,0 +1,110 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+var frontMatterDelimiters = map[string]string{
+	"---": FormatYAML,
+	"+++": FormatTOML,
+}
+
+// SplitFrontMatter separates the front matter block at the top of a
+// markdown file from its body. YAML front matter is delimited by "---"
+// lines and TOML front matter by "+++" lines. format is empty when the
+// content has no front matter.
+func SplitFrontMatter(content []byte) (format string, frontMatter []byte, body []byte) {
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+
+	firstLine, rest, found := cutLine(content)
+	if !found {
+		return "", nil, content
+	}
+
+	delimiter := string(bytes.TrimSpace(firstLine))
+	format, ok := frontMatterDelimiters[delimiter]
+	if !ok {
+		return "", nil, content
+	}
+
+	remaining := rest
+	for {
+		line, next, found := cutLine(remaining)
+		if string(bytes.TrimSpace(line)) == delimiter {
+			return format, rest[:len(rest)-len(remaining)], next
+		}
+		if !found {
+			// No closing delimiter, treat everything as content
+			return "", nil, content
+		}
+		remaining = next
+	}
+}
+
+// cutLine returns the first line of content without its line ending and
+// the content after it. found is false when content has no line ending.
+func cutLine(content []byte) (line []byte, rest []byte, found bool) {
+	line, rest, found = bytes.Cut(content, []byte("\n"))
+	return bytes.TrimSuffix(line, []byte("\r")), rest, found
+}
+
+// ParseFrontMatter decodes the front matter at the top of content and
+// returns it together with the remaining markdown body. Dates are
+// converted to strings so the values can be used as any other text field.
+func ParseFrontMatter(content []byte) (map[string]interface{}, []byte, error) {
+	format, raw, body := SplitFrontMatter(content)
+
+	frontMatter := make(map[string]interface{})
+
+	switch format {
+	case FormatYAML:
+		if err := yaml.Unmarshal(raw, &frontMatter); err != nil {
+			return nil, nil, fmt.Errorf("invalid YAML front matter: %w", err)
+		}
+	case FormatTOML:
+		if err := toml.Unmarshal(raw, &frontMatter); err != nil {
+			return nil, nil, fmt.Errorf("invalid TOML front matter: %w", err)
+		}
+	}
+
+	if frontMatter == nil {
+		// An empty YAML document decodes to a nil map
+		frontMatter = make(map[string]interface{})
+	}
+
+	return normalizeFrontMatter(frontMatter).(map[string]interface{}), body, nil
+}
+
+func normalizeFrontMatter(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeFrontMatter(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeFrontMatter(item)
+		}
+		return v
+	case time.Time:
+		if v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 && v.Nanosecond() == 0 {
+			return v.Format(time.DateOnly)
+		}
+		return v.Format(time.RFC3339)
+	case toml.LocalDate, toml.LocalDateTime, toml.LocalTime:
+		return fmt.Sprint(v)
+	default:
+		return v
+	}
+}
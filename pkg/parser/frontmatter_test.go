@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitFrontMatter_YAML(t *testing.T) {
+	format, frontMatter, body := SplitFrontMatter([]byte("---\ntitle: Hello\n---\n# Body\n"))
+
+	assert.Equal(t, FormatYAML, format)
+	assert.Equal(t, "title: Hello\n", string(frontMatter))
+	assert.Equal(t, "# Body\n", string(body))
+}
+
+func TestSplitFrontMatter_TOMLWithCRLF(t *testing.T) {
+	format, frontMatter, body := SplitFrontMatter([]byte("+++\r\ntitle = \"Hello\"\r\n+++\r\nBody"))
+
+	assert.Equal(t, FormatTOML, format)
+	assert.Equal(t, "title = \"Hello\"\r\n", string(frontMatter))
+	assert.Equal(t, "Body", string(body))
+}
+
+func TestSplitFrontMatter_NoFrontMatter(t *testing.T) {
+	content := []byte("# Title\n---\nMore")
+	format, frontMatter, body := SplitFrontMatter(content)
+
+	assert.Equal(t, "", format)
+	assert.Nil(t, frontMatter)
+	assert.Equal(t, content, body)
+}
+
+func TestSplitFrontMatter_Unclosed(t *testing.T) {
+	content := []byte("---\ntitle: Hello\n# Body\n")
+	format, _, body := SplitFrontMatter(content)
+
+	assert.Equal(t, "", format)
+	assert.Equal(t, content, body)
+}
+
+func TestParseFrontMatter_NormalizesDates(t *testing.T) {
+	yamlFM, _, err := ParseFrontMatter([]byte("---\npublished-at: 2024-03-01\ntags: [go]\n---\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-03-01", yamlFM["published-at"])
+	assert.Equal(t, []interface{}{"go"}, yamlFM["tags"])
+
+	tomlFM, _, err := ParseFrontMatter([]byte("+++\npublished-at = 2024-03-01\n+++\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-03-01", tomlFM["published-at"])
+}
+
+func TestParseFrontMatter_Invalid(t *testing.T) {
+	_, _, err := ParseFrontMatter([]byte("---\ntitle: [unclosed\n---\n"))
+	assert.Error(t, err)
+}
+
+func TestMarkdownToHtml_StripsFrontMatter(t *testing.T) {
+	markdownPath := filepath.Join(t.TempDir(), "post.md")
+	err := os.WriteFile(markdownPath, []byte("---\ntitle: Hello\n---\n# Heading\n"), 0644)
+	assert.NoError(t, err)
+
+	page, err := MarkdownToHtml(markdownPath, "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Heading", page.Title)
+	assert.Equal(t, "Hello", page.FrontMatter["title"])
+	assert.NotContains(t, string(page.HTML), "title: Hello")
+}
@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// pageUrlKey holds the url of the page being rendered, relative links and
+// images are resolved against it
+var pageUrlKey = parser.NewContextKey()
+
+// linkResolver rewrites relative link and image destinations, e.g.
+// "cover.png" in /blog/post/index.html becomes /blog/post/cover.png, so
+// they keep working wherever the html is included: listings, feeds, ...
+type linkResolver struct{}
+
+func (r *linkResolver) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	pageUrl, _ := pc.Get(pageUrlKey).(string)
+	if pageUrl == "" {
+		return
+	}
+
+	base, err := url.Parse(pageUrl)
+	if err != nil {
+		return
+	}
+
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch node := n.(type) {
+		case *ast.Link:
+			node.Destination = resolveUrl(base, node.Destination)
+		case *ast.Image:
+			node.Destination = resolveUrl(base, node.Destination)
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+// resolveUrl resolves a relative destination against the page url. Absolute
+// urls, absolute paths and fragments are kept as they are.
+func resolveUrl(base *url.URL, destination []byte) []byte {
+	dest := string(destination)
+	if dest == "" || strings.HasPrefix(dest, "/") || strings.HasPrefix(dest, "#") || strings.HasPrefix(dest, "?") {
+		return destination
+	}
+
+	ref, err := url.Parse(dest)
+	if err != nil || ref.IsAbs() || ref.Host != "" {
+		return destination
+	}
+
+	return []byte(base.ResolveReference(ref).String())
+}
@@ -0,0 +1,33 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderMarkdown_ResolvesRelativeLinks(t *testing.T) {
+	content := "![Cover](cover.png)\n\n" +
+		"[Notes](../notes/index.html#intro) [Home](/) [Top](#top) [Go](https://go.dev)\n\n" +
+		"<!--more-->\n\n![Diagram](images/diagram.svg)\n"
+
+	page, err := RenderMarkdown([]byte(content), "/blog/post/index.html")
+	assert.NoError(t, err)
+
+	html := string(page.HTML)
+	assert.Contains(t, html, `src="/blog/post/cover.png"`)
+	assert.Contains(t, html, `src="/blog/post/images/diagram.svg"`)
+	assert.Contains(t, html, `href="/blog/notes/index.html#intro"`)
+	assert.Contains(t, html, `href="/"`)
+	assert.Contains(t, html, `href="#top"`)
+	assert.Contains(t, html, `href="https://go.dev"`)
+	assert.Contains(t, string(page.Summary), `src="/blog/post/cover.png"`)
+
+	page, err = RenderMarkdown([]byte(content), "https://example.com/blog/post/index.html")
+	assert.NoError(t, err)
+	assert.Contains(t, string(page.HTML), `src="https://example.com/blog/post/cover.png"`)
+
+	page, err = RenderMarkdown([]byte(content), "")
+	assert.NoError(t, err)
+	assert.Contains(t, string(page.HTML), `src="cover.png"`)
+}
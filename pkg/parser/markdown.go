@@ -2,9 +2,10 @@ package parser
 
 import (
 	"bytes"
+	"fmt"
 	"html/template"
-	"log"
 	"os"
+	"strings"
 
 	"github.com/saasuke-labs/gengo/pkg/nagare"
 	"github.com/yuin/goldmark"
@@ -14,11 +15,23 @@ import (
 	"github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/renderer/html"
 	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
 )
 
 type HtmlPage struct {
-	Title string
-	HTML  template.HTML
+	Title       string
+	HTML        template.HTML
+	FrontMatter map[string]interface{}
+	// Summary is the content before <!--more-->, or the beginning of the
+	// text when there is no such separator. Truncated is set when the
+	// summary is shorter than the content.
+	Summary   template.HTML
+	Truncated bool
+	// Toc lists the headings of the content, except for the title
+	Toc             []TocEntry
+	TableOfContents template.HTML
+	WordCount       int
+	ReadingTime     int // In minutes
 }
 
 var md goldmark.Markdown
@@ -39,35 +52,78 @@ func init() {
 
 	md = goldmark.New(
 		goldmark.WithExtensions(extensions...),
-		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+		goldmark.WithParserOptions(
+			parser.WithAutoHeadingID(),
+			parser.WithASTTransformers(util.Prioritized(&linkResolver{}, 100)),
+		),
 		goldmark.WithRendererOptions(html.WithHardWraps(), html.WithXHTML()),
 	)
 }
 
-func MarkdownToHtml(markdownPath string) HtmlPage {
+// MarkdownToHtml renders a markdown file. Relative links and images are
+// resolved against pageUrl, the url the page is published at, unless it is
+// empty.
+func MarkdownToHtml(markdownPath, pageUrl string) (HtmlPage, error) {
 	content, err := os.ReadFile(markdownPath)
 	if err != nil {
-		log.Fatalf("failed to read %s: %v", markdownPath, err)
+		return HtmlPage{}, fmt.Errorf("failed to read %s: %w", markdownPath, err)
 	}
-	context := parser.NewContext()
-	doc := md.Parser().Parse(text.NewReader(content), parser.WithContext(context))
 
-	title := ""
-	h1 := findFirstH1(doc, content)
-
-	if h1 != "" {
-		title = h1
+	frontMatter, content, err := ParseFrontMatter(content)
+	if err != nil {
+		return HtmlPage{}, fmt.Errorf("%s: %w", markdownPath, err)
+	}
 
+	page, err := RenderMarkdown(content, pageUrl)
+	if err != nil {
+		return HtmlPage{}, fmt.Errorf("failed to render %s: %w", markdownPath, err)
 	}
+	page.FrontMatter = frontMatter
 
-	var article bytes.Buffer
+	return page, nil
+}
+
+// RenderMarkdown renders a markdown body, without front matter, resolving
+// relative links against pageUrl like MarkdownToHtml
+func RenderMarkdown(content []byte, pageUrl string) (HtmlPage, error) {
+	content, summarySource, hasMore := splitMore(content)
+
+	doc := parse(content, pageUrl)
 
-	md.Renderer().Render(&article, content, doc)
+	title := findFirstH1(doc, content)
+
+	var article bytes.Buffer
+	if err := md.Renderer().Render(&article, content, doc); err != nil {
+		return HtmlPage{}, err
+	}
 
-	return HtmlPage{
+	page := HtmlPage{
 		Title: title,
 		HTML:  template.HTML(article.String()),
+		Toc:   tableOfContents(doc, content),
 	}
+	page.TableOfContents = renderToc(page.Toc)
+	page.WordCount = wordCount(page.HTML)
+	page.ReadingTime = readingTime(page.WordCount)
+
+	if hasMore {
+		var summary bytes.Buffer
+		if err := md.Renderer().Render(&summary, summarySource, parse(summarySource, pageUrl)); err != nil {
+			return HtmlPage{}, err
+		}
+		page.Summary = template.HTML(strings.TrimSpace(summary.String()))
+		page.Truncated = true
+	} else {
+		page.Summary, page.Truncated = autoSummary(page.HTML, summaryWords)
+	}
+
+	return page, nil
+}
+
+func parse(content []byte, pageUrl string) ast.Node {
+	context := parser.NewContext()
+	context.Set(pageUrlKey, pageUrl)
+	return md.Parser().Parse(text.NewReader(content), parser.WithContext(context))
 }
 
 func findFirstH1(doc ast.Node, source []byte) string {
@@ -78,3 +134,19 @@ func findFirstH1(doc ast.Node, source []byte) string {
 	}
 	return ""
 }
+
+// Markdownify renders a markdown snippet. A single paragraph is rendered
+// without the wrapping <p>, so the result can be used inline.
+func Markdownify(content string) (template.HTML, error) {
+	var out bytes.Buffer
+	if err := md.Convert([]byte(content), &out); err != nil {
+		return "", err
+	}
+
+	html := strings.TrimSpace(out.String())
+	if strings.HasPrefix(html, "<p>") && strings.HasSuffix(html, "</p>") && strings.Count(html, "<p>") == 1 {
+		html = strings.TrimSuffix(strings.TrimPrefix(html, "<p>"), "</p>")
+	}
+
+	return template.HTML(html), nil
+}
@@ -0,0 +1,17 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkdownify(t *testing.T) {
+	html, err := Markdownify("Hello *world*")
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello <em>world</em>", string(html))
+
+	html, err = Markdownify("One\n\nTwo")
+	assert.NoError(t, err)
+	assert.Equal(t, "<p>One</p>\n<p>Two</p>", string(html))
+}
@@ -0,0 +1,153 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// Separator marking the end of the summary in the markdown content
+const moreSeparator = "<!--more-->"
+
+// Number of words of the summaries generated automatically
+const summaryWords = 70
+
+// Average reading speed, in words per minute
+const wordsPerMinute = 200
+
+// TocEntry is a heading of the table of contents
+type TocEntry struct {
+	Level int
+	ID    string
+	Text  string
+}
+
+// splitMore removes the <!--more--> separator from the content, returning
+// the content before it as the summary source.
+func splitMore(content []byte) ([]byte, []byte, bool) {
+	index := bytes.Index(content, []byte(moreSeparator))
+	if index < 0 {
+		return content, nil, false
+	}
+
+	summary := content[:index]
+	rest := content[index+len(moreSeparator):]
+
+	return append(append([]byte{}, summary...), rest...), summary, true
+}
+
+var htmlTagRegex = regexp.MustCompile(`<[^>]*>`)
+
+// plainText returns the text of the html, without tags
+func plainText(content template.HTML) string {
+	return html.UnescapeString(htmlTagRegex.ReplaceAllString(string(content), " "))
+}
+
+func wordCount(content template.HTML) int {
+	return len(strings.Fields(plainText(content)))
+}
+
+// readingTime returns the minutes needed to read the words, at least one
+// for any content.
+func readingTime(words int) int {
+	if words == 0 {
+		return 0
+	}
+	return (words + wordsPerMinute - 1) / wordsPerMinute
+}
+
+var titleRegex = regexp.MustCompile(`(?s)<h1[^>]*>.*?</h1>`)
+
+// autoSummary returns the first words of the text of the content, leaving
+// out the title.
+func autoSummary(content template.HTML, words int) (template.HTML, bool) {
+	text := string(content)
+	if loc := titleRegex.FindStringIndex(text); loc != nil {
+		text = text[:loc[0]] + text[loc[1]:]
+	}
+
+	fields := strings.Fields(plainText(template.HTML(text)))
+	truncated := len(fields) > words
+	if truncated {
+		fields = fields[:words]
+	}
+
+	summary := html.EscapeString(strings.Join(fields, " "))
+	if truncated {
+		summary += "…"
+	}
+	return template.HTML(summary), truncated
+}
+
+// tableOfContents lists the headings of the document, skipping the first
+// H1 as it is the title of the page.
+func tableOfContents(doc ast.Node, source []byte) []TocEntry {
+	entries := make([]TocEntry, 0)
+	skippedTitle := false
+
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		heading, ok := n.(*ast.Heading)
+		if !ok {
+			continue
+		}
+		if heading.Level == 1 && !skippedTitle {
+			skippedTitle = true
+			continue
+		}
+
+		id := ""
+		if value, ok := heading.AttributeString("id"); ok {
+			if b, ok := value.([]byte); ok {
+				id = string(b)
+			}
+		}
+
+		entries = append(entries, TocEntry{
+			Level: heading.Level,
+			ID:    id,
+			Text:  string(heading.Text(source)),
+		})
+	}
+
+	return entries
+}
+
+// renderToc renders the entries as nested lists, following the heading
+// levels.
+func renderToc(entries []TocEntry) template.HTML {
+	if len(entries) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(`<nav class="toc">`)
+
+	levels := []int{}
+	for i, entry := range entries {
+		if len(levels) == 0 || entry.Level > levels[len(levels)-1] {
+			b.WriteString("<ul>")
+			levels = append(levels, entry.Level)
+		} else {
+			for len(levels) > 1 && entry.Level < levels[len(levels)-1] && entry.Level <= levels[len(levels)-2] {
+				b.WriteString("</li></ul>")
+				levels = levels[:len(levels)-1]
+			}
+			if i > 0 {
+				b.WriteString("</li>")
+			}
+		}
+		fmt.Fprintf(&b, `<li><a href="#%s">%s</a>`, html.EscapeString(entry.ID), html.EscapeString(entry.Text))
+	}
+
+	for range levels {
+		b.WriteString("</li></ul>")
+	}
+	b.WriteString("</nav>")
+
+	return template.HTML(b.String())
+}
@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderMarkdown_MoreSeparator(t *testing.T) {
+	page, err := RenderMarkdown([]byte("# Title\n\nIntro *text*.\n\n<!--more-->\n\nRest of the post.\n"), "")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Title", page.Title)
+	assert.True(t, page.Truncated)
+	assert.Contains(t, string(page.Summary), "<p>Intro <em>text</em>.</p>")
+	assert.NotContains(t, string(page.Summary), "Rest")
+	assert.NotContains(t, string(page.HTML), "more")
+	assert.Contains(t, string(page.HTML), "Rest of the post.")
+}
+
+func TestRenderMarkdown_AutoSummary(t *testing.T) {
+	long := strings.Repeat("word ", 100)
+	page, err := RenderMarkdown([]byte("# Title\n\n"+long), "")
+	assert.NoError(t, err)
+
+	assert.True(t, page.Truncated)
+	assert.Equal(t, strings.TrimSpace(strings.Repeat("word ", summaryWords))+"…", string(page.Summary))
+	assert.Equal(t, 101, page.WordCount)
+	assert.Equal(t, 1, page.ReadingTime)
+
+	page, err = RenderMarkdown([]byte("Short <b>and</b> sweet & simple"), "")
+	assert.NoError(t, err)
+	assert.False(t, page.Truncated)
+	assert.Equal(t, "Short and sweet &amp; simple", string(page.Summary))
+}
+
+func TestRenderMarkdown_Toc(t *testing.T) {
+	page, err := RenderMarkdown([]byte("# Title\n\n## Setup\n\n### Install go\n\n### Configure\n\n## Usage\n"), "")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []TocEntry{
+		{Level: 2, ID: "setup", Text: "Setup"},
+		{Level: 3, ID: "install-go", Text: "Install go"},
+		{Level: 3, ID: "configure", Text: "Configure"},
+		{Level: 2, ID: "usage", Text: "Usage"},
+	}, page.Toc)
+
+	assert.Equal(t,
+		`<nav class="toc"><ul>`+
+			`<li><a href="#setup">Setup</a><ul>`+
+			`<li><a href="#install-go">Install go</a></li>`+
+			`<li><a href="#configure">Configure</a></li></ul></li>`+
+			`<li><a href="#usage">Usage</a></li></ul></nav>`,
+		string(page.TableOfContents))
+}
+
+func TestReadingTime(t *testing.T) {
+	assert.Equal(t, 0, readingTime(0))
+	assert.Equal(t, 1, readingTime(1))
+	assert.Equal(t, 2, readingTime(201))
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Slow browsers are dropped instead of blocking the other ones
+const writeTimeout = 5 * time.Second
+
+// clients is the registry of the connected browsers, with the url of the
+// page they show. It is safe for concurrent use: browsers connect and
+// disconnect in the goroutines of their requests while builds notify them
+// from another one. Writes are serialized, as a WebSocket connection
+// supports a single writer.
+type clients struct {
+	mu    sync.Mutex
+	pages map[*websocket.Conn]string
+}
+
+func newClients() *clients {
+	return &clients{pages: make(map[*websocket.Conn]string)}
+}
+
+func (c *clients) add(conn *websocket.Conn, page string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pages[conn] = page
+}
+
+func (c *clients) remove(conn *websocket.Conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pages, conn)
+}
+
+func (c *clients) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.pages)
+}
+
+// send sends every browser the messages returned for the page it shows.
+// Browsers that cannot be written to are disconnected.
+func (c *clients) send(messages func(page string) []Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for conn, page := range c.pages {
+		c.write(conn, messages(page)...)
+	}
+}
+
+// sendTo sends the messages to a single browser
+func (c *clients) sendTo(conn *websocket.Conn, messages ...Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.pages[conn]; ok {
+		c.write(conn, messages...)
+	}
+}
+
+func (c *clients) write(conn *websocket.Conn, messages ...Message) {
+	for _, message := range messages {
+		conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		if err := conn.WriteJSON(message); err != nil {
+			log.Println("WebSocket write error:", err)
+			conn.Close()
+			delete(c.pages, conn)
+			return
+		}
+	}
+}
+
+// closeAll disconnects every browser
+func (c *clients) closeAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for conn := range c.pages {
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"),
+			time.Now().Add(time.Second))
+		conn.Close()
+		delete(c.pages, conn)
+	}
+}
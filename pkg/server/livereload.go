@@ -0,0 +1,227 @@
+package server
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+)
+
+// Types of the messages sent to the browsers
+const (
+	MessageReload      = "reload"       // Reload the page
+	MessagePageChanged = "page-changed" // The page at Url changed, patch it
+	MessageCSSChanged  = "css-changed"  // The stylesheet at Url changed, swap it
+	MessageBuildError  = "build-error"  // The build failed, show Errors
+	MessageBuildFixed  = "build-fixed"  // The build succeeded again
+)
+
+// Message is sent as JSON to the browsers over the WebSocket
+type Message struct {
+	Type   string       `json:"type"`
+	Url    string       `json:"url,omitempty"`
+	Errors []BuildError `json:"errors,omitempty"`
+}
+
+// BuildError describes a file that failed to generate
+type BuildError struct {
+	File     string `json:"file,omitempty"`
+	Template string `json:"template,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Message  string `json:"message"`
+}
+
+// Files generated for feed readers and crawlers, no page shows them
+var unreferencedFiles = map[string]bool{
+	"feed.xml":    true,
+	"atom.xml":    true,
+	"feed.json":   true,
+	"sitemap.xml": true,
+	"robots.txt":  true,
+}
+
+// messagesFor returns the messages a browser showing page gets when the
+// urls change. Stylesheets are swapped in every page, as any page can use
+// them, while other assets reload the page. Feeds, sitemaps and robots.txt
+// are ignored.
+func messagesFor(page string, urls []string) []Message {
+	messages := make([]Message, 0)
+
+	for _, url := range urls {
+		switch {
+		case unreferencedFiles[path.Base(url)]:
+			continue
+		case path.Ext(url) == ".css":
+			messages = append(messages, Message{Type: MessageCSSChanged, Url: url})
+		case !isPage(url):
+			return []Message{{Type: MessageReload}}
+		case pagePath(url) == page:
+			messages = append(messages, Message{Type: MessagePageChanged, Url: url})
+		}
+	}
+
+	return messages
+}
+
+// liveReloadSnippet returns the script injected in the served pages. It
+// connects to the WebSocket at socketPath, on the host the page was loaded
+// from so it works from other devices too, and applies the messages:
+// stylesheets are swapped in place, changed pages are patched, keeping the
+// scroll position, and build errors are shown in an overlay until the
+// build is fixed.
+func liveReloadSnippet(socketPath string) string {
+	path, _ := json.Marshal(socketPath)
+	return "<script>(" + strings.TrimSpace(liveReloadScript) + ")(" + string(path) + ");</script>"
+}
+
+const liveReloadScript = `
+(socketPath) => {
+	const protocol = location.protocol === "https:" ? "wss:" : "ws:";
+	const ws = new WebSocket(protocol + "//" + location.host + socketPath + "?path=" + encodeURIComponent(location.pathname));
+
+	ws.onmessage = (event) => {
+		const message = JSON.parse(event.data);
+		switch (message.type) {
+			case "css-changed":
+				swapStylesheet(message.url);
+				break;
+			case "page-changed":
+				patchPage();
+				break;
+			case "build-error":
+				showErrors(message.errors);
+				break;
+			case "build-fixed":
+				hideErrors();
+				break;
+			default:
+				location.reload();
+		}
+	};
+
+	const overlayId = "gengo-error-overlay";
+
+	function showErrors(errors) {
+		hideErrors();
+
+		const overlay = document.createElement("div");
+		overlay.id = overlayId;
+		overlay.style.cssText = "position:fixed;inset:0;z-index:2147483647;overflow:auto;" +
+			"padding:2rem;background:rgba(24,24,27,0.95);color:#f4f4f5;" +
+			"font:14px/1.5 ui-monospace,SFMono-Regular,Menlo,monospace;";
+
+		const title = document.createElement("h2");
+		title.textContent = "Build failed";
+		title.style.cssText = "margin:0 0 1rem;color:#f87171;font-size:1.25rem;";
+		overlay.appendChild(title);
+
+		for (const error of errors || []) {
+			const item = document.createElement("div");
+			item.style.cssText = "margin-bottom:1.5rem;";
+
+			let source = error.file || "";
+			if (error.template) {
+				source += " (template " + error.template;
+				if (error.line) source += ":" + error.line;
+				source += ")";
+			} else if (error.line) {
+				source += ":" + error.line;
+			}
+
+			const file = document.createElement("div");
+			file.textContent = source;
+			file.style.cssText = "color:#fbbf24;";
+			const text = document.createElement("pre");
+			text.textContent = error.message;
+			text.style.cssText = "margin:0.25rem 0 0;white-space:pre-wrap;";
+
+			item.append(file, text);
+			overlay.appendChild(item);
+		}
+
+		const hint = document.createElement("div");
+		hint.textContent = "The last good build is still served. This message disappears once the error is fixed.";
+		hint.style.cssText = "color:#a1a1aa;";
+		overlay.appendChild(hint);
+
+		document.body.appendChild(overlay);
+	}
+
+	function hideErrors() {
+		document.getElementById(overlayId)?.remove();
+	}
+
+	const isLocal = (link) => new URL(link.href, location.href).origin === location.origin;
+
+	const swap = (link) => {
+		const url = new URL(link.href, location.href);
+		url.searchParams.set("gengo-reload", Date.now());
+		const next = link.cloneNode();
+		next.href = url.toString();
+		next.onload = () => link.remove();
+		link.after(next);
+	};
+
+	function swapStylesheet(url) {
+		const links = [...document.querySelectorAll('link[rel="stylesheet"]')].filter(isLocal);
+		const changed = links.filter((link) => new URL(link.href, location.href).pathname === url);
+		// The stylesheet may be imported by another one
+		(changed.length > 0 ? changed : links).forEach(swap);
+	}
+
+	async function patchPage() {
+		const response = await fetch(location.href, { cache: "no-store" });
+		if (!response.ok) {
+			location.reload();
+			return;
+		}
+		const next = new DOMParser().parseFromString(await response.text(), "text/html");
+
+		const x = window.scrollX, y = window.scrollY;
+		morph(document.head, next.head);
+		morph(document.body, next.body);
+		window.scrollTo(x, y);
+	}
+
+	// morph updates the nodes of from to match to, keeping the nodes that
+	// did not change, so the state of the page is preserved
+	function morph(from, to) {
+		if (from.nodeType !== to.nodeType || from.nodeName !== to.nodeName) {
+			from.replaceWith(document.importNode(to, true));
+			return;
+		}
+		if (from.nodeType === Node.TEXT_NODE || from.nodeType === Node.COMMENT_NODE) {
+			if (from.nodeValue !== to.nodeValue) {
+				from.nodeValue = to.nodeValue;
+			}
+			return;
+		}
+		if (from.nodeType !== Node.ELEMENT_NODE) {
+			return;
+		}
+
+		for (const attr of [...from.attributes]) {
+			if (!to.hasAttribute(attr.name)) {
+				from.removeAttribute(attr.name);
+			}
+		}
+		for (const attr of [...to.attributes]) {
+			if (from.getAttribute(attr.name) !== attr.value) {
+				from.setAttribute(attr.name, attr.value);
+			}
+		}
+
+		// The error overlay is not part of the page
+		const fromChildren = [...from.childNodes].filter((child) => child.id !== overlayId);
+		const toChildren = [...to.childNodes];
+		toChildren.forEach((child, i) => {
+			if (i < fromChildren.length) {
+				morph(fromChildren[i], child);
+			} else {
+				from.appendChild(document.importNode(child, true));
+			}
+		});
+		fromChildren.slice(toChildren.length).forEach((child) => child.remove());
+	}
+}
+`
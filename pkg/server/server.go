@@ -1,94 +1,385 @@
 package server
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"mime"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/saasuke-labs/gengo/pkg/watcher"
 
 	"github.com/gorilla/websocket"
 )
 
-func fileHandler(sitePath string, watchMode bool, port int) http.Handler {
+// Path of the WebSocket the live reload script connects to
+const socketPath = "/ws"
+
+// Time given to the requests in flight to finish on shutdown
+const shutdownTimeout = 5 * time.Second
+
+// Config configures a Server
+type Config struct {
+	SitePath     string // Directory served
+	Host         string // Address to listen on, empty for every interface
+	Port         int
+	LiveReload   bool   // Inject the live reload script in the pages
+	NotFoundPage string // Page served for missing files, relative to SitePath, 404.html by default
+}
+
+// Server serves a generated site. With live reload the pages are updated
+// in the browsers whenever Reload or ReportErrors are called.
+type Server struct {
+	config   Config
+	clients  *clients
+	upgrader websocket.Upgrader
+
+	// Errors of the last build, shown to the browsers until a build succeeds
+	mu     sync.Mutex
+	errors []BuildError
+}
+
+func New(config Config) *Server {
+	if config.NotFoundPage == "" {
+		config.NotFoundPage = "404.html"
+	}
+	return &Server{config: config, clients: newClients()}
+}
+
+// Addr returns the address the server listens on
+func (s *Server) Addr() string {
+	return net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+}
+
+// Url returns the url the site is browsed at
+func (s *Server) Url() string {
+	host := s.config.Host
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return "http://" + net.JoinHostPort(host, strconv.Itoa(s.config.Port))
+}
+
+// Handler returns the handler serving the site, and the WebSocket of the
+// browsers with live reload
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/", s.fileHandler())
+	if s.config.LiveReload {
+		mux.HandleFunc(socketPath, s.wsHandler)
+	}
+	return mux
+}
+
+// ListenAndServe serves the site until ctx is done, then stops gracefully:
+// the browsers are disconnected and the requests in flight can finish.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	httpServer := &http.Server{
+		Addr:    s.Addr(),
+		Handler: s.Handler(),
+	}
+
+	fmt.Printf("Serving site at %s from %s\n", s.config.SitePath, s.Url())
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	fmt.Println("Shutting down the server")
+
+	// Hijacked WebSocket connections are not closed by Shutdown
+	s.clients.closeAll()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) fileHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		path := filepath.Join(sitePath, r.URL.Path)
-		info, err := os.Stat(path)
+		// Cleaning the url keeps the requests inside the site
+		urlPath := path.Clean("/" + r.URL.Path)
+		file := filepath.Join(s.config.SitePath, filepath.FromSlash(urlPath))
+
+		info, err := os.Stat(file)
 		if err != nil {
-			http.NotFound(w, r)
+			s.notFound(w, r)
 			return
 		}
 		if info.IsDir() {
-			path = filepath.Join(path, "index.html")
-		}
-
-		if watchMode && strings.HasSuffix(path, ".html") {
-			content, err := os.ReadFile(path)
-			if err != nil {
-				http.Error(w, "Failed to read file", 500)
+			// Relative links of the page are resolved against its directory
+			if !strings.HasSuffix(r.URL.Path, "/") {
+				target := urlPath + "/"
+				if r.URL.RawQuery != "" {
+					target += "?" + r.URL.RawQuery
+				}
+				http.Redirect(w, r, target, http.StatusMovedPermanently)
 				return
 			}
-			// Inject the WebSocket script before closing </body>
-			html := string(content)
-			wsScript := fmt.Sprintf(`
-				<script>
-					const ws = new WebSocket("ws://localhost:%d/ws");
-					ws.onmessage = () => window.location.reload();
-				</script>
-			</body>`, port)
-			injected := strings.Replace(html, "</body>", wsScript, 1)
-			w.Header().Set("Content-Type", "text/html")
-			w.Write([]byte(injected))
-		} else {
-			http.ServeFile(w, r, path)
+			file = filepath.Join(file, "index.html")
 		}
+
+		s.serveFile(w, r, file, http.StatusOK)
 	})
 }
 
-var clients = make(map[*websocket.Conn]bool)
-var upgrader = websocket.Upgrader{}
+// notFound serves the custom 404 page of the site when there is one
+func (s *Server) notFound(w http.ResponseWriter, r *http.Request) {
+	file := filepath.Join(s.config.SitePath, filepath.FromSlash(s.config.NotFoundPage))
+	if info, err := os.Stat(file); err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+	s.serveFile(w, r, file, http.StatusNotFound)
+}
 
-func wsHandler(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+// serveFile writes the file with its content type and cache headers. Pages
+// get the live reload script when enabled.
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request, file string, status int) {
+	f, err := os.Open(file)
+	if err != nil {
+		s.notFound(w, r)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		s.notFound(w, r)
+		return
+	}
+
+	if contentType := contentType(file); contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.Header().Set("Cache-Control", cacheControl(file, s.config.LiveReload))
+
+	var content io.ReadSeeker = f
+	if s.config.LiveReload && isHtml(file) {
+		html, err := io.ReadAll(f)
+		if err != nil {
+			http.Error(w, "Failed to read file", http.StatusInternalServerError)
+			return
+		}
+		content = bytes.NewReader(injectLiveReload(html))
+	}
+
+	if status != http.StatusOK {
+		// ServeContent would answer conditional and range requests with
+		// the status of the file
+		w.WriteHeader(status)
+		io.Copy(w, content)
+		return
+	}
+	http.ServeContent(w, r, file, info.ModTime(), content)
+}
+
+// injectLiveReload adds the live reload script before the closing body tag,
+// or at the end of pages without one
+func injectLiveReload(html []byte) []byte {
+	snippet := []byte(liveReloadSnippet(socketPath))
+
+	index := bytes.LastIndex(html, []byte("</body>"))
+	if index < 0 {
+		return append(html, snippet...)
+	}
+
+	injected := make([]byte, 0, len(html)+len(snippet))
+	injected = append(injected, html[:index]...)
+	injected = append(injected, snippet...)
+	return append(injected, html[index:]...)
+}
+
+// Content types of the files sites usually have, so they do not depend on
+// the mime tables of the system
+var contentTypes = map[string]string{
+	".html":        "text/html; charset=utf-8",
+	".css":         "text/css; charset=utf-8",
+	".js":          "text/javascript; charset=utf-8",
+	".mjs":         "text/javascript; charset=utf-8",
+	".json":        "application/json",
+	".xml":         "application/xml; charset=utf-8",
+	".rss":         "application/rss+xml; charset=utf-8",
+	".atom":        "application/atom+xml; charset=utf-8",
+	".txt":         "text/plain; charset=utf-8",
+	".svg":         "image/svg+xml",
+	".ico":         "image/x-icon",
+	".webmanifest": "application/manifest+json",
+	".woff":        "font/woff",
+	".woff2":       "font/woff2",
+	".wasm":        "application/wasm",
+}
+
+// contentType returns the content type of a file from its extension, or
+// "" to let the content be sniffed
+func contentType(file string) string {
+	ext := strings.ToLower(filepath.Ext(file))
+	if contentType, ok := contentTypes[ext]; ok {
+		return contentType
+	}
+	return mime.TypeByExtension(ext)
+}
+
+// cacheControl returns the caching policy of a file. Pages are always
+// revalidated so changes show up, and during development so are the
+// assets, as they change with every build. Pages with the live reload
+// script are not stored at all, they are patched with fresh copies.
+func cacheControl(file string, liveReload bool) string {
+	switch {
+	case isHtml(file) && liveReload:
+		return "no-store"
+	case isHtml(file) || liveReload:
+		return "no-cache"
+	default:
+		return "public, max-age=3600"
+	}
+}
+
+func isHtml(file string) bool {
+	ext := strings.ToLower(filepath.Ext(file))
+	return ext == ".html" || ext == ".htm"
+}
+
+func (s *Server) wsHandler(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Println("WebSocket upgrade error:", err)
 		return
 	}
 	defer conn.Close()
-	clients[conn] = true
+
+	s.clients.add(conn, pagePath(r.URL.Query().Get("path")))
+	defer s.clients.remove(conn)
+
+	// Pages loaded while the build is broken show the errors too
+	s.mu.Lock()
+	errs := s.errors
+	s.mu.Unlock()
+	if len(errs) > 0 {
+		s.clients.sendTo(conn, Message{Type: MessageBuildError, Errors: errs})
+	}
 
 	for {
-		_, _, err := conn.ReadMessage()
-		if err != nil {
-			delete(clients, conn)
-			break
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
 		}
 	}
 }
 
-// TODO - Notify clients only when the file
-// that affects the open page changed
-func notifyClients(filePath string) {
-	for conn := range clients {
-		err := conn.WriteMessage(websocket.TextMessage, []byte("reload"))
+// pagePath normalizes the url of a page, so /blog/ and /blog/index.html
+// are the same page
+func pagePath(url string) string {
+	url = strings.TrimSuffix(url, "index.html")
+	if !strings.HasPrefix(url, "/") {
+		url = "/" + url
+	}
+	return url
+}
+
+// isPage reports whether the url is an html page. Other files, such as
+// stylesheets or images, can be used by any page.
+func isPage(url string) bool {
+	return strings.HasSuffix(url, "/") || strings.HasSuffix(url, ".html")
+}
+
+// Reload tells the browsers about the changed urls: stylesheets are swapped
+// in place, pages showing a changed url are patched keeping their scroll
+// position, and other assets reload the page
+func (s *Server) Reload(urls ...string) {
+	s.clients.send(func(page string) []Message {
+		return messagesFor(page, urls)
+	})
+}
+
+// ReportErrors shows the errors of a failed build in the browsers, over the
+// pages of the last good build. Reporting no errors hides them once the
+// build is fixed.
+func (s *Server) ReportErrors(errs ...BuildError) {
+	s.mu.Lock()
+	previous := s.errors
+	s.errors = errs
+	s.mu.Unlock()
+
+	var message Message
+	switch {
+	case len(errs) > 0:
+		message = Message{Type: MessageBuildError, Errors: errs}
+	case len(previous) > 0:
+		message = Message{Type: MessageBuildFixed}
+	default:
+		return
+	}
+
+	s.clients.send(func(string) []Message {
+		return []Message{message}
+	})
+}
+
+// notifyFiles reloads the browsers affected by changes in the site
+// directory
+func (s *Server) notifyFiles(files []string) {
+	siteDir, err := filepath.Abs(s.config.SitePath)
+	if err != nil {
+		return
+	}
+
+	urls := make([]string, 0, len(files))
+	for _, file := range files {
+		rel, err := filepath.Rel(siteDir, file)
 		if err != nil {
-			log.Println("WebSocket write error:", err)
-			conn.Close()
-			delete(clients, conn)
+			continue
 		}
+		urls = append(urls, "/"+filepath.ToSlash(rel))
 	}
+	s.Reload(urls...)
 }
 
-func Serve(sitePath string, watchMode bool, port int) {
-	fmt.Println("Serving site at", sitePath, "from http://localhost:", port)
+// Serve serves the site until interrupted. In watch mode the browsers are
+// updated whenever the files of the site change.
+func Serve(config Config, watchMode bool) error {
+	config.LiveReload = watchMode
+	s := New(config)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	http.Handle("/", fileHandler(sitePath, watchMode, port))
 	if watchMode {
-		http.HandleFunc("/ws", wsHandler)
-		go watcher.WatchDir(sitePath, notifyClients)
+		w, err := watcher.New()
+		if err != nil {
+			return err
+		}
+		defer w.Close()
+
+		if err := w.Watch([]string{config.SitePath}); err != nil {
+			return err
+		}
+		go w.Run(s.notifyFiles)
 	}
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", port), nil))
+	return s.ListenAndServe(ctx)
 }
@@ -0,0 +1,251 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPagePath(t *testing.T) {
+	assert.Equal(t, "/", pagePath(""))
+	assert.Equal(t, "/", pagePath("/index.html"))
+	assert.Equal(t, "/blog/", pagePath("/blog/index.html"))
+	assert.Equal(t, "/blog/post.html", pagePath("/blog/post.html"))
+}
+
+func TestMessagesFor(t *testing.T) {
+	assert.Equal(t, []Message{{Type: MessagePageChanged, Url: "/blog/"}},
+		messagesFor("/blog/", []string{"/notes/", "/blog/"}))
+	assert.Equal(t, []Message{{Type: MessagePageChanged, Url: "/blog/index.html"}},
+		messagesFor("/blog/", []string{"/blog/index.html"}))
+
+	// Unaffected pages are left untouched
+	assert.Empty(t, messagesFor("/blog/", []string{"/notes/", "/blog/post.html"}))
+	assert.Empty(t, messagesFor("/blog/", nil))
+
+	// Stylesheets are swapped in every page
+	assert.Equal(t, []Message{{Type: MessageCSSChanged, Url: "/static/app.css"}},
+		messagesFor("/blog/", []string{"/notes/", "/static/app.css"}))
+
+	// Other assets can be used by any page
+	assert.Equal(t, []Message{{Type: MessageReload}},
+		messagesFor("/blog/", []string{"/static/app.css", "/static/app.js"}))
+
+	// Feeds, sitemaps and robots.txt are not shown by any page
+	assert.Equal(t, []Message{{Type: MessagePageChanged, Url: "/blog/"}},
+		messagesFor("/blog/", []string{"/blog/", "/blog/feed.xml", "/atom.xml", "/feed.json", "/sitemap.xml", "/robots.txt"}))
+	assert.Empty(t, messagesFor("/notes/", []string{"/blog/feed.xml", "/sitemap.xml"}))
+}
+
+func TestLiveReloadSnippet(t *testing.T) {
+	snippet := liveReloadSnippet("/ws")
+	assert.True(t, strings.HasPrefix(snippet, "<script>"))
+	assert.Contains(t, snippet, "location.host")
+	assert.Contains(t, snippet, `("/ws");</script>`)
+}
+
+func TestInjectLiveReload(t *testing.T) {
+	html := string(injectLiveReload([]byte("<html><body><p>Hi</p></body></html>")))
+	assert.True(t, strings.HasPrefix(html, "<html><body><p>Hi</p><script>"))
+	assert.True(t, strings.HasSuffix(html, "</script></body></html>"))
+
+	html = string(injectLiveReload([]byte("<p>Hi</p>")))
+	assert.True(t, strings.HasPrefix(html, "<p>Hi</p><script>"))
+}
+
+func TestContentType(t *testing.T) {
+	assert.Equal(t, "text/html; charset=utf-8", contentType("site/index.html"))
+	assert.Equal(t, "text/css; charset=utf-8", contentType("site/static/app.CSS"))
+	assert.Equal(t, "font/woff2", contentType("site/fonts/inter.woff2"))
+	assert.Equal(t, "image/png", contentType("site/images/logo.png"))
+	assert.Equal(t, "", contentType("site/CNAME"))
+}
+
+func TestCacheControl(t *testing.T) {
+	assert.Equal(t, "no-store", cacheControl("index.html", true))
+	assert.Equal(t, "no-cache", cacheControl("index.html", false))
+	assert.Equal(t, "no-cache", cacheControl("app.css", true))
+	assert.Equal(t, "public, max-age=3600", cacheControl("app.css", false))
+}
+
+func newTestSite(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return dir
+}
+
+func get(handler http.Handler, url string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, url, nil))
+	return recorder
+}
+
+func TestFileHandler(t *testing.T) {
+	site := newTestSite(t, map[string]string{
+		"index.html":      "<body>Home</body>",
+		"blog/index.html": "<body>Blog</body>",
+		"static/app.css":  "body {}",
+		"static/app.js":   "console.log(1)",
+	})
+	handler := New(Config{SitePath: site}).Handler()
+
+	res := get(handler, "/")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "<body>Home</body>", res.Body.String())
+	assert.Equal(t, "text/html; charset=utf-8", res.Header().Get("Content-Type"))
+
+	res = get(handler, "/static/app.css")
+	assert.Equal(t, "text/css; charset=utf-8", res.Header().Get("Content-Type"))
+	assert.Equal(t, "public, max-age=3600", res.Header().Get("Cache-Control"))
+
+	// Directories are served with a trailing slash, relative links need it
+	res = get(handler, "/blog?page=2")
+	assert.Equal(t, http.StatusMovedPermanently, res.Code)
+	assert.Equal(t, "/blog/?page=2", res.Header().Get("Location"))
+
+	// Requests cannot leave the site, even without the cleaning of the mux
+	res = get(New(Config{SitePath: site}).fileHandler(), "/../../etc/passwd")
+	assert.Equal(t, http.StatusNotFound, res.Code)
+
+	// The live reload script is only injected when enabled
+	res = get(New(Config{SitePath: site, LiveReload: true}).Handler(), "/blog/")
+	assert.Contains(t, res.Body.String(), "<script>")
+	assert.Equal(t, "no-store", res.Header().Get("Cache-Control"))
+	assert.NotContains(t, get(handler, "/blog/").Body.String(), "<script>")
+}
+
+func TestNotFoundPage(t *testing.T) {
+	site := newTestSite(t, map[string]string{"404.html": "<body>Lost</body>"})
+
+	res := get(New(Config{SitePath: site}).Handler(), "/missing.html")
+	assert.Equal(t, http.StatusNotFound, res.Code)
+	assert.Equal(t, "<body>Lost</body>", res.Body.String())
+	assert.Equal(t, "text/html; charset=utf-8", res.Header().Get("Content-Type"))
+
+	res = get(New(Config{SitePath: site, NotFoundPage: "errors/404.html"}).Handler(), "/missing.html")
+	assert.Equal(t, http.StatusNotFound, res.Code)
+	assert.NotContains(t, res.Body.String(), "Lost")
+}
+
+func dial(t *testing.T, ts *httptest.Server, page string) *websocket.Conn {
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+socketPath+"?path="+page, nil)
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// waitForClients waits until the browsers are registered, the upgrade
+// answers before the registration
+func waitForClients(t *testing.T, s *Server, count int) {
+	assert.Eventually(t, func() bool { return s.clients.count() == count }, time.Second, time.Millisecond)
+}
+
+func TestReload(t *testing.T) {
+	s := New(Config{SitePath: t.TempDir(), LiveReload: true})
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	blog := dial(t, ts, "/blog/")
+	notes := dial(t, ts, "/notes/")
+	waitForClients(t, s, 2)
+
+	s.Reload("/blog/index.html")
+	s.Reload("/static/app.css")
+
+	var message Message
+	assert.NoError(t, blog.ReadJSON(&message))
+	assert.Equal(t, Message{Type: MessagePageChanged, Url: "/blog/index.html"}, message)
+
+	// Unaffected pages only get the stylesheet
+	message = Message{}
+	assert.NoError(t, notes.ReadJSON(&message))
+	assert.Equal(t, Message{Type: MessageCSSChanged, Url: "/static/app.css"}, message)
+
+	notes.Close()
+	waitForClients(t, s, 1)
+}
+
+func TestNotifyFiles(t *testing.T) {
+	dir := t.TempDir()
+	s := New(Config{SitePath: dir, LiveReload: true})
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	blog := dial(t, ts, "/blog/")
+	waitForClients(t, s, 1)
+
+	s.notifyFiles([]string{filepath.Join(dir, "blog", "index.html")})
+
+	var message Message
+	assert.NoError(t, blog.ReadJSON(&message))
+	assert.Equal(t, Message{Type: MessagePageChanged, Url: "/blog/index.html"}, message)
+}
+
+func TestReportErrors(t *testing.T) {
+	s := New(Config{SitePath: t.TempDir(), LiveReload: true})
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	errs := []BuildError{{File: "blog/post.md", Template: "page.html", Line: 3, Message: "unexpected EOF"}}
+	s.ReportErrors(errs...)
+
+	// Pages loaded while the build is broken get the errors
+	conn := dial(t, ts, "/")
+
+	var message Message
+	assert.NoError(t, conn.ReadJSON(&message))
+	assert.Equal(t, Message{Type: MessageBuildError, Errors: errs}, message)
+
+	s.ReportErrors()
+	var fixed Message
+	assert.NoError(t, conn.ReadJSON(&fixed))
+	assert.Equal(t, Message{Type: MessageBuildFixed}, fixed)
+}
+
+func TestListenAndServe(t *testing.T) {
+	s := New(Config{SitePath: t.TempDir(), Host: "127.0.0.1", Port: freePort(t)})
+	assert.Equal(t, "127.0.0.1:"+strconv.Itoa(s.config.Port), s.Addr())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.ListenAndServe(ctx) }()
+
+	assert.Eventually(t, func() bool {
+		res, err := http.Get(s.Url() + "/")
+		if err != nil {
+			return false
+		}
+		res.Body.Close()
+		return res.StatusCode == http.StatusNotFound
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(shutdownTimeout):
+		t.Fatal("server did not shut down")
+	}
+}
+
+func freePort(t *testing.T) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
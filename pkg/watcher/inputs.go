@@ -0,0 +1,189 @@
+package watcher
+
+import (
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Changes arriving within this delay are reported together, editors and
+// builds often write several files at once
+const debounce = 100 * time.Millisecond
+
+// Watcher reports changes to a set of files and directories. Directories
+// are watched recursively. Files are watched through their directory, so
+// files replaced on save, or created later, are noticed too.
+type Watcher struct {
+	fs *fsnotify.Watcher
+
+	mu      sync.Mutex
+	paths   []string
+	ignored []string
+}
+
+// New creates a watcher that never reports changes inside the ignored
+// directories, such as the output of the build.
+func New(ignored ...string) (*Watcher, error) {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{fs: fsWatcher}
+	for _, dir := range ignored {
+		w.ignored = append(w.ignored, absPath(dir))
+	}
+	return w, nil
+}
+
+// Watch replaces the watched paths. It can be called again while Run is
+// reporting changes, e.g. when the manifest lists new files.
+func (w *Watcher) Watch(paths []string) error {
+	watched := make([]string, 0, len(paths))
+
+	for _, path := range paths {
+		path = absPath(path)
+		watched = append(watched, path)
+
+		info, err := os.Stat(path)
+		switch {
+		case err == nil && info.IsDir():
+			if err := w.addTree(path); err != nil {
+				return err
+			}
+		case err == nil || os.IsNotExist(err):
+			// Missing paths are noticed when their directory gets them
+			if dir := filepath.Dir(path); isDir(dir) {
+				if err := w.fs.Add(dir); err != nil {
+					return err
+				}
+			}
+		default:
+			return err
+		}
+	}
+
+	w.mu.Lock()
+	w.paths = watched
+	w.mu.Unlock()
+
+	return nil
+}
+
+// Run calls callback with the changed paths, sorted, until the watcher is
+// closed. The callback runs in the goroutine of Run, changes happening
+// while it runs are reported in the next call.
+func (w *Watcher) Run(callback func(changed []string)) {
+	pending := make(map[string]bool)
+	var timer <-chan time.Time
+
+	for {
+		select {
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+
+			if event.Has(fsnotify.Create) && isDir(event.Name) && w.matches(event.Name) {
+				if err := w.addTree(event.Name); err != nil {
+					log.Println("Watcher error:", err)
+				}
+			}
+			if event.Op == fsnotify.Chmod || !w.matches(event.Name) {
+				continue
+			}
+
+			pending[event.Name] = true
+			timer = time.After(debounce)
+
+		case <-timer:
+			changed := make([]string, 0, len(pending))
+			for path := range pending {
+				changed = append(changed, path)
+			}
+			sort.Strings(changed)
+
+			pending = make(map[string]bool)
+			timer = nil
+
+			callback(changed)
+
+		case err, ok := <-w.fs.Errors:
+			if !ok {
+				return
+			}
+			log.Println("Watcher error:", err)
+		}
+	}
+}
+
+func (w *Watcher) Close() error {
+	return w.fs.Close()
+}
+
+// matches reports whether the path is one of the watched paths or inside
+// one of the watched directories
+func (w *Watcher) matches(path string) bool {
+	path = absPath(path)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, dir := range w.ignored {
+		if isWithin(path, dir) {
+			return false
+		}
+	}
+	for _, watched := range w.paths {
+		if isWithin(path, watched) {
+			return true
+		}
+	}
+	return false
+}
+
+// addTree watches a directory and its subdirectories, except for hidden
+// and ignored ones
+func (w *Watcher) addTree(root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		for _, dir := range w.ignored {
+			if isWithin(absPath(path), dir) {
+				return filepath.SkipDir
+			}
+		}
+		return w.fs.Add(path)
+	})
+}
+
+func isWithin(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func absPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	return abs
+}
@@ -0,0 +1,75 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatcher_ReportsWatchedChanges(t *testing.T) {
+	dir := t.TempDir()
+	for _, path := range []string{"posts", "output", "layouts"} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, path), 0755))
+	}
+	layout := filepath.Join(dir, "layouts", "layout.html")
+	assert.NoError(t, os.WriteFile(layout, []byte("layout"), 0644))
+
+	w, err := New(filepath.Join(dir, "output"))
+	assert.NoError(t, err)
+	defer w.Close()
+
+	assert.NoError(t, w.Watch([]string{filepath.Join(dir, "posts"), layout}))
+
+	changes := make(chan []string, 10)
+	go w.Run(func(changed []string) {
+		changes <- changed
+	})
+
+	// Ignored, unwatched and watched changes
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "output", "index.html"), []byte(""), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "layouts", "other.html"), []byte(""), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "posts", "first.md"), []byte("# First"), 0644))
+	assert.NoError(t, os.WriteFile(layout, []byte("changed"), 0644))
+
+	select {
+	case changed := <-changes:
+		assert.Equal(t, []string{layout, filepath.Join(dir, "posts", "first.md")}, changed)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no changes reported")
+	}
+}
+
+func TestWatcher_WatchesNewDirectories(t *testing.T) {
+	dir := t.TempDir()
+
+	w, err := New()
+	assert.NoError(t, err)
+	defer w.Close()
+	assert.NoError(t, w.Watch([]string{dir}))
+
+	changes := make(chan []string, 10)
+	go w.Run(func(changed []string) {
+		changes <- changed
+	})
+
+	bundle := filepath.Join(dir, "hello")
+	assert.NoError(t, os.Mkdir(bundle, 0755))
+	<-changes
+
+	assert.NoError(t, os.WriteFile(filepath.Join(bundle, "index.md"), []byte("# Hello"), 0644))
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case changed := <-changes:
+			if assert.NotEmpty(t, changed) && changed[len(changed)-1] == filepath.Join(bundle, "index.md") {
+				return
+			}
+		case <-timeout:
+			t.Fatal("change in the new directory not reported")
+		}
+	}
+}
@@ -9,7 +9,11 @@ import (
 func main() {
 	fmt.Println("Testing gengo parser with nagare content...")
 
-	result := parser.MarkdownToHtml("test-nagare.md")
+	result, err := parser.MarkdownToHtml("test-nagare.md", "")
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
 
 	fmt.Printf("Title: %s\n", result.Title)
 	fmt.Printf("HTML: %s\n", result.HTML)